@@ -0,0 +1,354 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+)
+
+/* Byte array functions */
+
+// assertBytes asserts that `arg` is a byte array and returns its bytes
+func assertBytes(arg types.MalType) ([]byte, error) {
+	b, ok := arg.(types.MalBytes)
+	if !ok {
+		return nil, fmt.Errorf("incorrect arguments type: MalBytes is expected")
+	}
+	return b.Value, nil
+}
+
+// assertEncoding returns the lower-cased name of the encoding in optional `args`, "utf-8" by default
+func assertEncoding(args []types.MalType) (string, error) {
+	if len(args) == 0 {
+		return "utf-8", nil
+	}
+	if err := AssertLength(args, 1); err != nil {
+		return "", err
+	}
+	name, ok := args[0].(types.MalString)
+	if !ok {
+		return "", fmt.Errorf("incorrect arguments type: MalString is expected")
+	}
+	switch encoding := strings.ToLower(name.Value); encoding {
+	case "utf-8", "utf8":
+		return "utf-8", nil
+	case "latin-1", "latin1", "iso-8859-1":
+		return "latin-1", nil
+	case "ascii", "us-ascii":
+		return "ascii", nil
+	default:
+		return "", fmt.Errorf("unsupported encoding: %s", name.Value)
+	}
+}
+
+// createBytes is the mal function `bytes`, which makes a byte array of a sequence of integers
+func createBytes(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if b, ok := args[0].(types.MalBytes); ok {
+		return b, nil
+	}
+	elements, err := types.SeqToList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, len(elements))
+	for i, element := range elements {
+		n, err := assertNumber(element)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n > 255 {
+			return nil, fmt.Errorf("byte out of range: %d", n)
+		}
+		result[i] = byte(n)
+	}
+	return types.MalBytes{Value: result}, nil
+}
+
+func isBytes(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalBytes)
+	return types.ToMalBool(ok), nil
+}
+
+func slurpBytes(args ...types.MalType) (types.MalType, error) {
+	filepath, err := assertOneString(args)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return types.MalBytes{Value: content}, nil
+}
+
+func spitBytes(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	filepath, err := assertOneString(args[:1])
+	if err != nil {
+		return nil, err
+	}
+	content, err := assertBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath, content, 0644); err != nil {
+		return nil, err
+	}
+	return types.MalNil, nil
+}
+
+// subbytes returns the bytes from `start` (inclusive) to `end` (exclusive, the length by default)
+func subbytes(args ...types.MalType) (types.MalType, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect 2 or 3 but get %d", len(args))
+	}
+	content, err := assertBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := assertNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	end := len(content)
+	if len(args) == 3 {
+		if end, err = assertNumber(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if start < 0 || end > len(content) || start > end {
+		return nil, fmt.Errorf("range [%d, %d) out of bounds for %d byte(s)", start, end, len(content))
+	}
+	return types.MalBytes{Value: content[start:end]}, nil // safe to share as bytes are never modified
+}
+
+// bytesToString decodes bytes with an optional encoding
+func bytesToString(args ...types.MalType) (types.MalType, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect at least 1 but get 0")
+	}
+	content, err := assertBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := assertEncoding(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	switch encoding {
+	case "utf-8":
+		if !utf8.Valid(content) {
+			return nil, fmt.Errorf("invalid utf-8 data")
+		}
+		return types.MalString{Value: string(content)}, nil
+	default: // latin-1 or ascii, where each byte is a character
+		runes := make([]rune, len(content))
+		for i, b := range content {
+			if encoding == "ascii" && b > 127 {
+				return nil, fmt.Errorf("invalid ascii byte: %d", b)
+			}
+			runes[i] = rune(b)
+		}
+		return types.MalString{Value: string(runes)}, nil
+	}
+}
+
+// stringToBytes encodes a string with an optional encoding
+func stringToBytes(args ...types.MalType) (types.MalType, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect at least 1 but get 0")
+	}
+	s, err := assertOneString(args[:1])
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := assertEncoding(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if encoding == "utf-8" {
+		return types.MalBytes{Value: []byte(s)}, nil
+	}
+	limit := rune(255)
+	if encoding == "ascii" {
+		limit = 127
+	}
+	result := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > limit {
+			return nil, fmt.Errorf("character %+q can't be encoded in %s", r, encoding)
+		}
+		result = append(result, byte(r))
+	}
+	return types.MalBytes{Value: result}, nil
+}
+
+func bytesToHex(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	content, err := assertBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return types.MalString{Value: hex.EncodeToString(content)}, nil
+}
+
+func hexToBytes(args ...types.MalType) (types.MalType, error) {
+	s, err := assertOneString(args)
+	if err != nil {
+		return nil, err
+	}
+	content, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex string: %v", err)
+	}
+	return types.MalBytes{Value: content}, nil
+}
+
+func bytesToBase64(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	content, err := assertBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return types.MalString{Value: base64.StdEncoding.EncodeToString(content)}, nil
+}
+
+func base64ToBytes(args ...types.MalType) (types.MalType, error) {
+	s, err := assertOneString(args)
+	if err != nil {
+		return nil, err
+	}
+	content, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 string: %v", err)
+	}
+	return types.MalBytes{Value: content}, nil
+}
+
+// uintAt reads an unsigned integer of `size` bytes at `offset`, in big-endian unless :little is given
+func uintAt(args ...types.MalType) (types.MalType, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect 3 or 4 but get %d", len(args))
+	}
+	content, err := assertBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	offset, err := assertNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	size, err := assertNumber(args[2])
+	if err != nil {
+		return nil, err
+	}
+	littleEndian := false
+	if len(args) == 4 {
+		switch args[3] {
+		case types.MalKeyword{Value: "little"}:
+			littleEndian = true
+		case types.MalKeyword{Value: "big"}:
+		default:
+			return nil, fmt.Errorf("byte order should be :big or :little")
+		}
+	}
+	if size < 1 || size > 7 {
+		return nil, fmt.Errorf("size should be between 1 and 7 but get %d", size)
+	}
+	if offset < 0 || offset+size > len(content) {
+		return nil, fmt.Errorf("range [%d, %d) out of bounds for %d byte(s)", offset, offset+size, len(content))
+	}
+	result := 0
+	for i := 0; i < size; i++ {
+		b := content[offset+i]
+		if littleEndian {
+			b = content[offset+size-1-i]
+		}
+		result = result<<8 | int(b)
+	}
+	return types.MalNumber{Value: result}, nil
+}
+
+// bitwise applies `op` to integers from left to right
+func bitwise(args []types.MalType, op func(a, b int) int) (types.MalType, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect at least 1 but get 0")
+	}
+	result, err := assertNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args[1:] {
+		n, err := assertNumber(arg)
+		if err != nil {
+			return nil, err
+		}
+		result = op(result, n)
+	}
+	return types.MalNumber{Value: result}, nil
+}
+
+func bitAnd(args ...types.MalType) (types.MalType, error) {
+	return bitwise(args, func(a, b int) int { return a & b })
+}
+
+func bitOr(args ...types.MalType) (types.MalType, error) {
+	return bitwise(args, func(a, b int) int { return a | b })
+}
+
+func bitXor(args ...types.MalType) (types.MalType, error) {
+	return bitwise(args, func(a, b int) int { return a ^ b })
+}
+
+func bitNot(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	n, err := assertNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return types.MalNumber{Value: ^n}, nil
+}
+
+func bitShiftLeft(args ...types.MalType) (types.MalType, error) {
+	return bitShift(args, func(a int, n uint) int { return a << n })
+}
+
+func bitShiftRight(args ...types.MalType) (types.MalType, error) {
+	return bitShift(args, func(a int, n uint) int { return a >> n })
+}
+
+func bitShift(args []types.MalType, op func(a int, n uint) int) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	a, err := assertNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := assertNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > 63 {
+		return nil, fmt.Errorf("shift count should be between 0 and 63 but get %d", n)
+	}
+	return types.MalNumber{Value: op(a, uint(n))}, nil
+}
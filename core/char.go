@@ -0,0 +1,35 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"unicode/utf8"
+)
+
+/* Character functions */
+
+// toChar is the mal function `char`, which converts a code point to a character
+func toChar(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if c, ok := args[0].(types.MalChar); ok {
+		return c, nil
+	}
+	code, err := assertNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+		return nil, fmt.Errorf("invalid code point: %d", code)
+	}
+	return types.MalChar{Value: rune(code)}, nil
+}
+
+func isChar(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalChar)
+	return types.ToMalBool(ok), nil
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* First-class continuations */
+
+// Continuations are implemented on top of the error returning convention of EVAL: invoking one
+// returns an escape error, which unwinds all the Go calls in between (including the TCO loop of
+// EVAL) till it reaches the call/cc that captured the continuation. Hence they are escaping (or
+// one-shot) continuations, which can only be invoked before call/cc returns, i.e., within their
+// dynamic extent. Re-entering a continuation would require a CPS evaluator.
+
+// continuation holds the state of a continuation captured by call/cc
+type continuation struct {
+	active bool // whether the call/cc capturing it hasn't returned yet
+}
+
+// escape is the error returned by invoking a continuation
+// Any code catching errors must pass it through (wrapping is fine), or the continuation won't work
+type escape struct {
+	k     *continuation
+	value types.MalType
+}
+
+func (e *escape) Error() string {
+	return "continuation invoked outside of its call/cc"
+}
+
+// IsEscape reports whether `err` is (or wraps) the escape of invoking a continuation,
+// which is a means of control flow rather than a real error
+func IsEscape(err error) bool {
+	var e *escape
+	return errors.As(err, &e)
+}
+
+func callCC(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	k := &continuation{active: true}
+	kFunction := types.MalFunction(func(args ...types.MalType) (types.MalType, error) {
+		if !k.active {
+			return nil, fmt.Errorf("re-entering a continuation is not supported")
+		}
+		var value types.MalType = types.MalNil
+		switch len(args) {
+		case 0:
+		case 1:
+			value = args[0]
+		default:
+			return nil, fmt.Errorf("incorrect number of arguments: expect 0 or 1 but get %d", len(args))
+		}
+		return nil, &escape{k: k, value: value}
+	})
+	result, err := CallFunction(args[0], kFunction)
+	k.active = false
+	var e *escape
+	if errors.As(err, &e) && e.k == k { // the continuation is invoked
+		return e.value, nil
+	}
+	return result, err
+}
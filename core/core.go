@@ -17,17 +17,22 @@ func AssertLength(args []types.MalType, expect int) error {
 	return nil
 }
 
-// assertTwoNumbers asserts that `args` are a list of two numbers
-func assertTwoNumbers(args []types.MalType) (int, int, error) {
-	if err := AssertLength(args, 2); err != nil {
-		return 0, 0, err
-	}
-	a, ok1 := args[0].(types.MalNumber)
-	b, ok2 := args[1].(types.MalNumber)
-	if !ok1 || !ok2 {
-		return 0, 0, fmt.Errorf("invalid operand(s)")
+// CallFunction calls a mal function `f` (either builtin or defined with fn*) with `args`
+func CallFunction(f types.MalType, args ...types.MalType) (types.MalType, error) {
+	switch fn := f.(type) {
+	case types.MalFunction:
+		return fn(args...)
+	case types.MalFunctionTCO:
+		return fn.Function(args...)
+	case types.MalKeyword:
+		return CallKeyword(fn, args...)
+	case *types.MalMultiFn:
+		return CallMulti(fn, args...)
+	case *types.MalTracedFn:
+		return fn.Function(args...)
+	default:
+		return nil, fmt.Errorf("invalid function calling")
 	}
-	return a.Value, b.Value, nil
 }
 
 // assertOneString asserts that `args` is just a list of one string
@@ -42,67 +47,52 @@ func assertOneString(args []types.MalType) (string, error) {
 	return str.Value, nil
 }
 
-func add(args ...types.MalType) (types.MalType, error) {
-	a, b, err := assertTwoNumbers(args)
-	if err != nil {
-		return nil, err
-	}
-	return types.MalNumber{Value: a + b}, nil
-}
-
-func sub(args ...types.MalType) (types.MalType, error) {
-	a, b, err := assertTwoNumbers(args)
-	if err != nil {
-		return nil, err
-	}
-	return types.MalNumber{Value: a - b}, nil
-}
-
-func mul(args ...types.MalType) (types.MalType, error) {
-	a, b, err := assertTwoNumbers(args)
-	if err != nil {
-		return nil, err
-	}
-	return types.MalNumber{Value: a * b}, nil
-}
-
-func div(args ...types.MalType) (types.MalType, error) {
-	a, b, err := assertTwoNumbers(args)
-	if err != nil {
-		return nil, err
-	}
-	if b == 0 {
-		return nil, fmt.Errorf("division by zero")
-	}
-	return types.MalNumber{Value: a / b}, nil
-}
-
 /* String functions */
 
 // toJoinedString converts each element in `values` to string and concatenates them with `sep`
-func toJoinedString(values []types.MalType, sep string, readable bool) string {
+// An error in realizing a lazy sequence among `values` is returned rather than printed
+func toJoinedString(values []types.MalType, sep string, readable bool) (string, error) {
 	strList := make([]string, 0, len(values))
 	for _, v := range values {
+		if err := printer.Realize(v); err != nil {
+			return "", err
+		}
 		strList = append(strList, printer.PrintStr(v, readable))
 	}
-	return strings.Join(strList, sep)
+	return strings.Join(strList, sep), nil
 }
 
 func strReadable(args ...types.MalType) (types.MalType, error) {
-	return types.MalString{Value: toJoinedString(args, " ", true)}, nil
+	s, err := toJoinedString(args, " ", true)
+	if err != nil {
+		return nil, err
+	}
+	return types.MalString{Value: s}, nil
 }
 
 func strUnreadable(args ...types.MalType) (types.MalType, error) {
-	return types.MalString{Value: toJoinedString(args, "", false)}, nil
+	s, err := toJoinedString(args, "", false)
+	if err != nil {
+		return nil, err
+	}
+	return types.MalString{Value: s}, nil
 }
 
 func printReadable(args ...types.MalType) (types.MalType, error) {
-	fmt.Println(toJoinedString(args, " ", true))
+	s, err := toJoinedString(args, " ", true)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println(s)
 	return types.MalNil, nil
 }
 
 func printUnreadable(args ...types.MalType) (types.MalType, error) {
-	fmt.Println(toJoinedString(args, " ", false))
+	s, err := toJoinedString(args, " ", false)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println(s)
 	return types.MalNil, nil
 }
 
@@ -125,6 +115,28 @@ func isEmptyList(args ...types.MalType) (types.MalType, error) {
 	if err := AssertLength(args, 1); err != nil {
 		return nil, err
 	}
+	if ls, ok := args[0].(*types.MalLazySeq); ok {
+		_, _, notEmpty, err := types.SeqNext(ls)
+		if err != nil {
+			return nil, err
+		}
+		return types.ToMalBool(!notEmpty), nil
+	}
+	if set, ok := args[0].(types.MalSet); ok {
+		return types.ToMalBool(set.Count() == 0), nil
+	}
+	if vec, ok := args[0].(types.MalVector); ok {
+		return types.ToMalBool(vec.Count() == 0), nil
+	}
+	if hm, ok := args[0].(types.MalHashmap); ok {
+		return types.ToMalBool(hm.Count() == 0), nil
+	}
+	if record, ok := args[0].(types.MalRecord); ok {
+		return types.ToMalBool(record.Fields().Count() == 0), nil
+	}
+	if b, ok := args[0].(types.MalBytes); ok {
+		return types.ToMalBool(len(b.Value) == 0), nil
+	}
 	lst, ok := args[0].(types.MalList)
 	if !ok {
 		return nil, fmt.Errorf("can't check whether a non-list is empty")
@@ -141,6 +153,34 @@ func getListSize(args ...types.MalType) (types.MalType, error) {
 	if ok && literal == types.MalNil {
 		return types.MalNumber{Value: 0}, nil
 	}
+	// MalLazySeq, which needs realizing all of its elements
+	if ls, ok := args[0].(*types.MalLazySeq); ok {
+		lst, err := types.SeqToList(ls)
+		if err != nil {
+			return nil, err
+		}
+		return types.MalNumber{Value: len(lst)}, nil
+	}
+	// MalSet
+	if set, ok := args[0].(types.MalSet); ok {
+		return types.MalNumber{Value: set.Count()}, nil
+	}
+	// MalVector
+	if vec, ok := args[0].(types.MalVector); ok {
+		return types.MalNumber{Value: vec.Count()}, nil
+	}
+	// MalHashmap
+	if hm, ok := args[0].(types.MalHashmap); ok {
+		return types.MalNumber{Value: hm.Count()}, nil
+	}
+	// MalRecord
+	if record, ok := args[0].(types.MalRecord); ok {
+		return types.MalNumber{Value: record.Fields().Count()}, nil
+	}
+	// MalBytes
+	if b, ok := args[0].(types.MalBytes); ok {
+		return types.MalNumber{Value: len(b.Value)}, nil
+	}
 	// MalList
 	lst, ok := args[0].(types.MalList)
 	if !ok {
@@ -153,41 +193,9 @@ func isEqual(args ...types.MalType) (types.MalType, error) {
 	if err := AssertLength(args, 2); err != nil {
 		return nil, err
 	}
-	same := true
-	switch first := args[0].(type) {
-	case types.MalList:
-		second, ok := args[1].(types.MalList)
-		// necessary condition for equality: second should be a list of the same length as first
-		if !ok || len(first) != len(second) {
-			same = false
-			break
-		}
-		for i := 0; i < len(first); i++ {
-			if b, _ := isEqual(first[i], second[i]); b == types.MalFalse {
-				same = false
-				break
-			}
-		}
-	case types.MalNumber:
-		second, ok := args[1].(types.MalNumber)
-		same = ok && first.Value == second.Value
-	case types.MalLiteral:
-		second, ok := args[1].(types.MalLiteral)
-		same = ok && first == second
-	case types.MalString:
-		second, ok := args[1].(types.MalString)
-		same = ok && first.Value == second.Value
-	case types.MalKeyword:
-		second, ok := args[1].(types.MalKeyword)
-		same = ok && first.Value == second.Value
-	case types.MalVector:
-		second, ok := args[1].(types.MalVector)
-		if ok { // convert both to MalList and then compare
-			return isEqual(types.MalList(first), types.MalList(second))
-		}
-		same = false
-	default:
-		return nil, fmt.Errorf("sorry but unimplemented yet")
+	same, err := types.Equal(args[0], args[1])
+	if err != nil {
+		return nil, err
 	}
 	return types.ToMalBool(same), nil
 }
@@ -196,20 +204,20 @@ func isEqual(args ...types.MalType) (types.MalType, error) {
 // and =. But considering the complexity of =, I implement both < and >.
 
 func isLess(args ...types.MalType) (types.MalType, error) {
-	a, b, err := assertTwoNumbers(args)
+	cmp, err := compareNumbers(args)
 	if err != nil {
 		return nil, err
 	}
-	return types.ToMalBool(a < b), nil
+	return types.ToMalBool(cmp < 0), nil
 
 }
 
 func isGreater(args ...types.MalType) (types.MalType, error) {
-	a, b, err := assertTwoNumbers(args)
+	cmp, err := compareNumbers(args)
 	if err != nil {
 		return nil, err
 	}
-	return types.ToMalBool(a > b), nil
+	return types.ToMalBool(cmp > 0), nil
 }
 
 func isLessEqual(args ...types.MalType) (types.MalType, error) {
@@ -236,6 +244,27 @@ func readString(args ...types.MalType) (types.MalType, error) {
 	return reader.ReadStr(inputStr)
 }
 
+// checkSyntax reports all syntax errors in a string, each as a map like {:line 1 :column 2 :message "..."}
+func checkSyntax(args ...types.MalType) (types.MalType, error) {
+	inputStr, err := assertOneString(args)
+	if err != nil {
+		return nil, err
+	}
+	result := types.MalList{}
+	for _, d := range reader.Check(inputStr) {
+		diagnostic, err := types.NewHashmap(
+			types.MalKeyword{Value: "line"}, types.MalNumber{Value: d.Position.Line},
+			types.MalKeyword{Value: "column"}, types.MalNumber{Value: d.Position.Column},
+			types.MalKeyword{Value: "message"}, types.MalString{Value: d.Message},
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, diagnostic)
+	}
+	return result, nil
+}
+
 func slurp(args ...types.MalType) (types.MalType, error) {
 	filepath, err := assertOneString(args)
 	if err != nil {
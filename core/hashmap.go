@@ -0,0 +1,122 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* Hash map functions */
+
+// hash returns the hash of a value, which is the same for equal values
+func hash(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	h, err := types.Hash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return types.MalNumber{Value: int(h)}, nil
+}
+
+func createHashmap(args ...types.MalType) (types.MalType, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect key-value pairs")
+	}
+	return types.NewHashmap(args...)
+}
+
+func isHashmap(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalHashmap)
+	return types.ToMalBool(ok), nil
+}
+
+// get looks up a key in a hash map, a record or a set, or an index in a vector
+// It returns the default value (nil if not given) when nothing is found
+func get(args ...types.MalType) (types.MalType, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect 2 or 3 but get %d", len(args))
+	}
+	var notFound types.MalType = types.MalNil
+	if len(args) == 3 {
+		notFound = args[2]
+	}
+	switch coll := args[0].(type) {
+	case types.MalHashmap:
+		if value, ok := coll.Get(args[1]); ok {
+			return value, nil
+		}
+	case types.MalRecord:
+		if value, ok := coll.Get(args[1]); ok {
+			return value, nil
+		}
+	case types.MalSet:
+		if coll.Contains(args[1]) {
+			return args[1], nil
+		}
+	case types.MalVector:
+		if index, ok := args[1].(types.MalNumber); ok && index.Value >= 0 && index.Value < coll.Count() {
+			return coll.Nth(index.Value)
+		}
+	}
+	return notFound, nil
+}
+
+func dissoc(args ...types.MalType) (types.MalType, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect at least 1 but get 0")
+	}
+	if record, ok := args[0].(types.MalRecord); ok {
+		// like Clojure, it's no longer a record without any of the declared fields
+		hm := record.Fields()
+		isRecord := true
+		for _, key := range args[1:] {
+			hm = hm.Dissoc(key)
+			isRecord = isRecord && !record.Type.IsField(key)
+		}
+		if isRecord {
+			return types.NewRecord(record.Type, hm), nil
+		}
+		return hm, nil
+	}
+	hm, ok := args[0].(types.MalHashmap)
+	if !ok {
+		return nil, fmt.Errorf("incorrect arguments type: MalHashmap is expected")
+	}
+	for _, key := range args[1:] {
+		hm = hm.Dissoc(key)
+	}
+	return hm, nil
+}
+
+func keys(args ...types.MalType) (types.MalType, error) {
+	return mapEntries(args, func(entry types.MapEntry) types.MalType { return entry.Key })
+}
+
+func vals(args ...types.MalType) (types.MalType, error) {
+	return mapEntries(args, func(entry types.MapEntry) types.MalType { return entry.Value })
+}
+
+// mapEntries returns a list of what `pick` picks from each entry of a hash map or a record
+func mapEntries(args []types.MalType, pick func(types.MapEntry) types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	var entries []types.MapEntry
+	switch t := args[0].(type) {
+	case types.MalHashmap:
+		entries = t.Entries()
+	case types.MalRecord:
+		entries = t.Entries()
+	default:
+		return nil, fmt.Errorf("incorrect arguments type: MalHashmap is expected")
+	}
+	result := make(types.MalList, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, pick(entry))
+	}
+	return result, nil
+}
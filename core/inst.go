@@ -0,0 +1,52 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* Instant and UUID functions */
+
+func isInst(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalInst)
+	return types.ToMalBool(ok), nil
+}
+
+// instMs returns the number of milliseconds since the Unix epoch
+func instMs(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	inst, ok := args[0].(types.MalInst)
+	if !ok {
+		return nil, fmt.Errorf("incorrect arguments type: MalInst is expected")
+	}
+	return types.MalNumber{Value: int(inst.Value.UnixNano() / 1e6)}, nil
+}
+
+func isUUID(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalUUID)
+	return types.ToMalBool(ok), nil
+}
+
+// randomUUID generates a random (version 4) UUID
+func randomUUID(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 0); err != nil {
+		return nil, err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant 10
+	s := fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	return types.MalUUID{Value: s}, nil
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/printer"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* Multimethods and the keyword hierarchy */
+
+// defaultDispatch is the dispatch value of the method used when no other method matches
+var defaultDispatch = types.MalKeyword{Value: "default"}
+
+// hierarchy maps a keyword or symbol to the set of its parents, as declared by derive
+var hierarchy = types.MalHashmap{}
+
+// isFunction tells whether `f` can be called by CallFunction()
+func isFunction(f types.MalType) bool {
+	switch f.(type) {
+	case types.MalFunction, types.MalFunctionTCO, types.MalKeyword, *types.MalMultiFn, *types.MalTracedFn:
+		return true
+	}
+	return false
+}
+
+// NewMultiFn creates a multimethod named `name` without any method
+func NewMultiFn(name string, dispatch types.MalType) (*types.MalMultiFn, error) {
+	if !isFunction(dispatch) {
+		return nil, fmt.Errorf("the dispatch function of '%s' is not a function", name)
+	}
+	return &types.MalMultiFn{Name: name, Dispatch: dispatch}, nil
+}
+
+// AddMethod registers `method` for `value` in `multi`, replacing the existing one
+func AddMethod(multi *types.MalMultiFn, value, method types.MalType) error {
+	if !isFunction(method) {
+		return fmt.Errorf("the method of '%s' is not a function", multi.Name)
+	}
+	methods, err := multi.Methods.Assoc(value, method)
+	if err != nil {
+		return err
+	}
+	multi.Methods = methods
+	return nil
+}
+
+// findMethod returns the method for the dispatch value `value`, trying an exact match first, then
+// the most specific dispatch value which `value` isa?, and finally the :default method
+func findMethod(multi *types.MalMultiFn, value types.MalType) (types.MalType, error) {
+	if method, ok := multi.Methods.Get(value); ok {
+		return method, nil
+	}
+	var best *types.MapEntry
+	for _, entry := range multi.Methods.Entries() {
+		if !isA(value, entry.Key) {
+			continue
+		}
+		if best == nil || isA(entry.Key, best.Key) {
+			entry := entry
+			best = &entry
+		} else if !isA(best.Key, entry.Key) {
+			return nil, fmt.Errorf("multiple methods in '%s' match dispatch value %s: %s and %s", multi.Name,
+				printer.PrintStr(value, true), printer.PrintStr(best.Key, true), printer.PrintStr(entry.Key, true))
+		}
+	}
+	if best != nil {
+		return best.Value, nil
+	}
+	if method, ok := multi.Methods.Get(defaultDispatch); ok {
+		return method, nil
+	}
+	return nil, fmt.Errorf("no method in '%s' for dispatch value: %s", multi.Name, printer.PrintStr(value, true))
+}
+
+// CallMulti calls the method of `multi` selected by the dispatch value of `args`
+func CallMulti(multi *types.MalMultiFn, args ...types.MalType) (types.MalType, error) {
+	value, err := CallFunction(multi.Dispatch, args...)
+	if err != nil {
+		return nil, err
+	}
+	method, err := findMethod(multi, value)
+	if err != nil {
+		return nil, err
+	}
+	return CallFunction(method, args...)
+}
+
+func assertMultiFn(arg types.MalType) (*types.MalMultiFn, error) {
+	multi, ok := arg.(*types.MalMultiFn)
+	if !ok {
+		return nil, fmt.Errorf("incorrect arguments type: multimethod is expected")
+	}
+	return multi, nil
+}
+
+func removeMethod(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	multi, err := assertMultiFn(args[0])
+	if err != nil {
+		return nil, err
+	}
+	multi.Methods = multi.Methods.Dissoc(args[1])
+	return multi, nil
+}
+
+// methods returns a hash map of dispatch values to methods
+func methods(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	multi, err := assertMultiFn(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return multi.Methods, nil
+}
+
+// isA tells whether `child` is `parent`, or derives from it directly or indirectly
+// Vectors are compared element by element, e.g., [:square :circle] isa? [:shape :shape]
+func isA(child, parent types.MalType) bool {
+	if same, _ := types.Equal(child, parent); same {
+		return true
+	}
+	if cv, ok := child.(types.MalVector); ok {
+		pv, ok := parent.(types.MalVector)
+		if !ok || cv.Count() != pv.Count() {
+			return false
+		}
+		children, parents := cv.Slice(), pv.Slice()
+		for i := range children {
+			if !isA(children[i], parents[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if parents, ok := hierarchy.Get(child); ok {
+		for _, p := range parents.(types.MalSet).Elements() {
+			if isA(p, parent) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func assertTag(arg types.MalType) error {
+	switch arg.(type) {
+	case types.MalKeyword, types.MalSymbol:
+		return nil
+	default:
+		return fmt.Errorf("incorrect arguments type: keyword or symbol is expected")
+	}
+}
+
+// derive declares that `child` is a kind of `parent` in the hierarchy
+func derive(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	child, parent := args[0], args[1]
+	if err := assertTag(child); err != nil {
+		return nil, err
+	}
+	if err := assertTag(parent); err != nil {
+		return nil, err
+	}
+	if isA(parent, child) {
+		return nil, fmt.Errorf("cyclic derivation: %s is already a %s",
+			printer.PrintStr(parent, true), printer.PrintStr(child, true))
+	}
+	parents := types.MalSet{}
+	if existing, ok := hierarchy.Get(child); ok {
+		parents = existing.(types.MalSet)
+	}
+	parents, _ = parents.Add(parent) // keywords and symbols are always hashable
+	hierarchy, _ = hierarchy.Assoc(child, parents)
+	return types.MalNil, nil
+}
+
+func isAFunction(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	return types.ToMalBool(isA(args[0], args[1])), nil
+}
+
+// parents returns the set of direct parents, or nil if there is none
+func parents(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if set, ok := hierarchy.Get(args[0]); ok {
+		return set, nil
+	}
+	return types.MalNil, nil
+}
+
+// ancestors returns the set of all parents, direct or indirect, or nil if there is none
+func ancestors(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	result := types.MalSet{}
+	pending := []types.MalType{args[0]}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if set, ok := hierarchy.Get(current); ok {
+			for _, p := range set.(types.MalSet).Elements() {
+				if !result.Contains(p) {
+					result, _ = result.Add(p)
+					pending = append(pending, p)
+				}
+			}
+		}
+	}
+	if result.Count() == 0 {
+		return types.MalNil, nil
+	}
+	return result, nil
+}
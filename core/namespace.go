@@ -11,18 +11,112 @@ var NameSpace = map[string]types.MalFunction{
 	"-": sub,
 	"*": mul,
 	"/": div,
+	// number conversions
+	"int":         toInt,
+	"float":       toFloatNumber,
+	"numerator":   numerator,
+	"denominator": denominator,
 	// string functions
-	"pr-str":      strReadable,
-	"str":         strUnreadable,
-	"prn":         printReadable,
-	"println":     printUnreadable,
-	"read-string": readString,
-	"slurp":       slurp,
+	"pr-str":       strReadable,
+	"str":          strUnreadable,
+	"prn":          printReadable,
+	"println":      printUnreadable,
+	"read-string":  readString,
+	"check-syntax": checkSyntax,
+	"slurp":        slurp,
+	// regex functions
+	"re-pattern": rePattern,
+	"regex?":     isRegex,
+	"re-find":    reFind,
+	"re-matches": reMatches,
+	"re-seq":     reSeq,
+	"re-groups":  reGroups,
+	"replace":    replace,
+	// byte array functions
+	"bytes":           createBytes,
+	"bytes?":          isBytes,
+	"slurp-bytes":     slurpBytes,
+	"spit-bytes":      spitBytes,
+	"subbytes":        subbytes,
+	"bytes->string":   bytesToString,
+	"string->bytes":   stringToBytes,
+	"bytes->hex":      bytesToHex,
+	"hex->bytes":      hexToBytes,
+	"bytes->base64":   bytesToBase64,
+	"base64->bytes":   base64ToBytes,
+	"uint-at":         uintAt,
+	"bit-and":         bitAnd,
+	"bit-or":          bitOr,
+	"bit-xor":         bitXor,
+	"bit-not":         bitNot,
+	"bit-shift-left":  bitShiftLeft,
+	"bit-shift-right": bitShiftRight,
+	// character functions
+	"char":  toChar,
+	"char?": isChar,
+	// instant and UUID functions
+	"inst?":       isInst,
+	"inst-ms":     instMs,
+	"uuid?":       isUUID,
+	"random-uuid": randomUUID,
+	// symbol functions
+	"gensym": gensym,
 	// list related operations
 	"list":   createList,
 	"list?":  isList,
 	"empty?": isEmptyList,
 	"count":  getListSize,
+	// sequence functions
+	"seq":        seq,
+	"first":      first,
+	"rest":       rest,
+	"cons":       cons,
+	"range":      createRange,
+	"iterate":    iterate,
+	"repeat":     repeatValue,
+	"cycle":      cycle,
+	"take":       take,
+	"drop":       drop,
+	"take-while": takeWhile,
+	// vector functions
+	"vector":  createVector,
+	"vec":     toVector,
+	"vector?": isVector,
+	"nth":     nth,
+	"assoc":   assoc,
+	// hash map functions
+	"hash-map": createHashmap,
+	"hash":     hash,
+	"map?":     isHashmap,
+	"get":      get,
+	"dissoc":   dissoc,
+	"keys":     keys,
+	"vals":     vals,
+	// record functions
+	"record?": isRecord,
+	// multimethods
+	"remove-method": removeMethod,
+	"methods":       methods,
+	"derive":        derive,
+	"isa?":          isAFunction,
+	"parents":       parents,
+	"ancestors":     ancestors,
+	// set functions
+	"set":          createSet,
+	"set?":         isSet,
+	"conj":         conj,
+	"disj":         disj,
+	"contains?":    contains,
+	"union":        union,
+	"intersection": intersection,
+	"difference":   difference,
+	"subset?":      isSubset,
+	// control flow
+	"call/cc":                        callCC,
+	"call-with-current-continuation": callCC,
+	// debugging
+	"trace-depth":  setTraceDepth,
+	"trace-output": setTraceOutput,
 	// comparision
 	"=":  isEqual,
 	"<":  isLess,
@@ -34,5 +128,5 @@ var NameSpace = map[string]types.MalFunction{
 // InitCommands contain mal commands to be executed in sequence during initialization
 var InitCommands = []string{
 	`(def! not (fn* (a) (if a false true)))`,
-	`(def! load-file (fn* (f) (eval (read-string (str "(do " (slurp f) "\nnil)")))))`,
+	`(def! *data-readers* {})`,
 }
@@ -0,0 +1,305 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"math"
+	"math/big"
+)
+
+/* Numeric tower */
+
+// numberRank is the level of a number type in the numeric tower
+// An operation on two numbers is done at the higher level of them, i.e., int + float = float
+// Integer operations overflowing int are redone with big integers, whose results are converted
+// back to int if they fit. Similarly, ratios with a denominator of 1 are converted to integers.
+type numberRank int
+
+const (
+	rankInt numberRank = iota
+	rankBig
+	rankRatio
+	rankFloat
+)
+
+// rankOf returns the rank of `n`, with false if `n` is not a number
+func rankOf(n types.MalType) (numberRank, bool) {
+	switch n.(type) {
+	case types.MalNumber:
+		return rankInt, true
+	case types.MalBigInt:
+		return rankBig, true
+	case types.MalRatio:
+		return rankRatio, true
+	case types.MalFloat:
+		return rankFloat, true
+	default:
+		return 0, false
+	}
+}
+
+// assertTwoNumbers asserts that `args` are a list of two numbers
+// It returns the rank at which they should be operated
+func assertTwoNumbers(args []types.MalType) (numberRank, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return 0, err
+	}
+	rankA, ok1 := rankOf(args[0])
+	rankB, ok2 := rankOf(args[1])
+	if !ok1 || !ok2 {
+		return 0, fmt.Errorf("invalid operand(s)")
+	}
+	if rankA > rankB {
+		return rankA, nil
+	}
+	return rankB, nil
+}
+
+// toBig converts an integer to *big.Int, which is safe to be modified
+func toBig(n types.MalType) *big.Int {
+	switch t := n.(type) {
+	case types.MalNumber:
+		return big.NewInt(int64(t.Value))
+	case types.MalBigInt:
+		return new(big.Int).Set(t.Value)
+	default:
+		return new(big.Int)
+	}
+}
+
+// toRat converts an integer or a ratio to *big.Rat, which is safe to be modified
+func toRat(n types.MalType) *big.Rat {
+	switch t := n.(type) {
+	case types.MalRatio:
+		return new(big.Rat).Set(t.Value)
+	default:
+		return new(big.Rat).SetInt(toBig(n))
+	}
+}
+
+// toFloat converts any number to float64
+func toFloat(n types.MalType) float64 {
+	switch t := n.(type) {
+	case types.MalNumber:
+		return float64(t.Value)
+	case types.MalBigInt:
+		f, _ := new(big.Float).SetInt(t.Value).Float64()
+		return f
+	case types.MalRatio:
+		f, _ := t.Value.Float64()
+		return f
+	case types.MalFloat:
+		return t.Value
+	default:
+		return math.NaN()
+	}
+}
+
+// arithmeticOps is an arithmetic operation implemented at each rank
+type arithmeticOps struct {
+	int   func(a, b int) (result int, ok bool) // not ok if it overflows (or fails otherwise)
+	big   func(a, b *big.Int) (types.MalType, error)
+	ratio func(a, b *big.Rat) (types.MalType, error)
+	float func(a, b float64) (types.MalType, error)
+}
+
+// arithmetic applies `ops` to two numbers in `args` after promoting them to the same rank
+func arithmetic(args []types.MalType, ops arithmeticOps) (types.MalType, error) {
+	rank, err := assertTwoNumbers(args)
+	if err != nil {
+		return nil, err
+	}
+	switch rank {
+	case rankInt:
+		if result, ok := ops.int(args[0].(types.MalNumber).Value, args[1].(types.MalNumber).Value); ok {
+			return types.MalNumber{Value: result}, nil
+		}
+		return ops.big(toBig(args[0]), toBig(args[1]))
+	case rankBig:
+		return ops.big(toBig(args[0]), toBig(args[1]))
+	case rankRatio:
+		return ops.ratio(toRat(args[0]), toRat(args[1]))
+	default:
+		return ops.float(toFloat(args[0]), toFloat(args[1]))
+	}
+}
+
+func add(args ...types.MalType) (types.MalType, error) {
+	return arithmetic(args, arithmeticOps{
+		int: func(a, b int) (int, bool) {
+			c := a + b
+			return c, (c > a) == (b > 0)
+		},
+		big: func(a, b *big.Int) (types.MalType, error) {
+			return types.NormalizeBigInt(a.Add(a, b)), nil
+		},
+		ratio: func(a, b *big.Rat) (types.MalType, error) {
+			return types.NormalizeRat(a.Add(a, b)), nil
+		},
+		float: func(a, b float64) (types.MalType, error) {
+			return types.MalFloat{Value: a + b}, nil
+		},
+	})
+}
+
+func sub(args ...types.MalType) (types.MalType, error) {
+	return arithmetic(args, arithmeticOps{
+		int: func(a, b int) (int, bool) {
+			c := a - b
+			return c, (c < a) == (b > 0)
+		},
+		big: func(a, b *big.Int) (types.MalType, error) {
+			return types.NormalizeBigInt(a.Sub(a, b)), nil
+		},
+		ratio: func(a, b *big.Rat) (types.MalType, error) {
+			return types.NormalizeRat(a.Sub(a, b)), nil
+		},
+		float: func(a, b float64) (types.MalType, error) {
+			return types.MalFloat{Value: a - b}, nil
+		},
+	})
+}
+
+func mul(args ...types.MalType) (types.MalType, error) {
+	return arithmetic(args, arithmeticOps{
+		int: func(a, b int) (int, bool) {
+			if a == 0 || b == 0 {
+				return 0, true
+			}
+			// note that the minimum int is the only non-zero int equal to its negation
+			if (a == -1 && b == -b) || (b == -1 && a == -a) {
+				return 0, false
+			}
+			c := a * b
+			return c, c/b == a
+		},
+		big: func(a, b *big.Int) (types.MalType, error) {
+			return types.NormalizeBigInt(a.Mul(a, b)), nil
+		},
+		ratio: func(a, b *big.Rat) (types.MalType, error) {
+			return types.NormalizeRat(a.Mul(a, b)), nil
+		},
+		float: func(a, b float64) (types.MalType, error) {
+			return types.MalFloat{Value: a * b}, nil
+		},
+	})
+}
+
+func div(args ...types.MalType) (types.MalType, error) {
+	return arithmetic(args, arithmeticOps{
+		int: func(a, b int) (int, bool) {
+			// let big integers handle division by zero, overflow and ratio results
+			if b == 0 || (b == -1 && a != 0 && a == -a) || a%b != 0 {
+				return 0, false
+			}
+			return a / b, true
+		},
+		big: func(a, b *big.Int) (types.MalType, error) {
+			if b.Sign() == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return types.NormalizeRat(new(big.Rat).SetFrac(a, b)), nil
+		},
+		ratio: func(a, b *big.Rat) (types.MalType, error) {
+			if b.Sign() == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return types.NormalizeRat(a.Quo(a, b)), nil
+		},
+		float: func(a, b float64) (types.MalType, error) { // division by zero gives infinity
+			return types.MalFloat{Value: a / b}, nil
+		},
+	})
+}
+
+// compareNumbers compares two numbers in `args`, returning -1, 0 or 1
+// Note that NaN is neither less than nor greater than any number
+func compareNumbers(args []types.MalType) (int, error) {
+	rank, err := assertTwoNumbers(args)
+	if err != nil {
+		return 0, err
+	}
+	switch rank {
+	case rankInt:
+		a, b := args[0].(types.MalNumber).Value, args[1].(types.MalNumber).Value
+		if a < b {
+			return -1, nil
+		} else if a > b {
+			return 1, nil
+		}
+		return 0, nil
+	case rankBig:
+		return toBig(args[0]).Cmp(toBig(args[1])), nil
+	case rankRatio:
+		return toRat(args[0]).Cmp(toRat(args[1])), nil
+	default:
+		a, b := toFloat(args[0]), toFloat(args[1])
+		if a < b {
+			return -1, nil
+		} else if a > b {
+			return 1, nil
+		}
+		return 0, nil
+	}
+}
+
+// toInt is the mal function `int`, which truncates a float or a ratio towards zero
+func toInt(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	switch t := args[0].(type) {
+	case types.MalNumber, types.MalBigInt:
+		return t, nil
+	case types.MalRatio:
+		return types.NormalizeBigInt(new(big.Int).Quo(t.Value.Num(), t.Value.Denom())), nil
+	case types.MalFloat:
+		if math.IsNaN(t.Value) || math.IsInf(t.Value, 0) {
+			return nil, fmt.Errorf("can't convert %v to an integer", t.Value)
+		}
+		integer, _ := big.NewFloat(t.Value).Int(nil)
+		return types.NormalizeBigInt(integer), nil
+	case types.MalChar: // the code point
+		return types.MalNumber{Value: int(t.Value)}, nil
+	default:
+		return nil, fmt.Errorf("incorrect arguments type: number or character is expected")
+	}
+}
+
+// toFloatNumber is the mal function `float`
+func toFloatNumber(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if _, ok := rankOf(args[0]); !ok {
+		return nil, fmt.Errorf("incorrect arguments type: number is expected")
+	}
+	return types.MalFloat{Value: toFloat(args[0])}, nil
+}
+
+// assertRational asserts that `args` is just a list of one integer or ratio
+func assertRational(args []types.MalType) (*big.Rat, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if rank, ok := rankOf(args[0]); !ok || rank > rankRatio {
+		return nil, fmt.Errorf("incorrect arguments type: integer or ratio is expected")
+	}
+	return toRat(args[0]), nil
+}
+
+func numerator(args ...types.MalType) (types.MalType, error) {
+	r, err := assertRational(args)
+	if err != nil {
+		return nil, err
+	}
+	return types.NormalizeBigInt(r.Num()), nil
+}
+
+func denominator(args ...types.MalType) (types.MalType, error) {
+	r, err := assertRational(args)
+	if err != nil {
+		return nil, err
+	}
+	return types.NormalizeBigInt(r.Denom()), nil
+}
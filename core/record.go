@@ -0,0 +1,73 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* Record functions */
+
+// DefineRecord defines a record type with `fields`, which should be a vector of symbols
+// It returns the functions to bind for the type, i.e., the positional constructor ->Name,
+// the map constructor map->Name and the predicate Name?
+func DefineRecord(name string, fields types.MalType) (map[string]types.MalFunction, error) {
+	vec, ok := fields.(types.MalVector)
+	if !ok {
+		return nil, fmt.Errorf("the fields of a record are expected to be a vector of symbols")
+	}
+	keywords := make([]types.MalKeyword, 0, vec.Count())
+	for _, field := range vec.Slice() {
+		symbol, ok := field.(types.MalSymbol)
+		if !ok {
+			return nil, fmt.Errorf("the fields of a record are expected to be a vector of symbols")
+		}
+		keywords = append(keywords, types.MalKeyword{Value: symbol.Value})
+	}
+	rt := types.DefineRecordType(name, keywords)
+	return map[string]types.MalFunction{
+		"->" + name: func(args ...types.MalType) (types.MalType, error) {
+			if len(args) != len(keywords) {
+				return nil, fmt.Errorf("incorrect number of arguments for '->%s': expect %d but get %d",
+					name, len(keywords), len(args))
+			}
+			hm := types.MalHashmap{}
+			for i, keyword := range keywords {
+				hm, _ = hm.Assoc(keyword, args[i])
+			}
+			return types.NewRecord(rt, hm), nil
+		},
+		"map->" + name: func(args ...types.MalType) (types.MalType, error) {
+			if err := AssertLength(args, 1); err != nil {
+				return nil, err
+			}
+			hm, ok := args[0].(types.MalHashmap)
+			if !ok {
+				return nil, fmt.Errorf("incorrect arguments type: MalHashmap is expected")
+			}
+			return types.NewRecord(rt, hm), nil
+		},
+		name + "?": func(args ...types.MalType) (types.MalType, error) {
+			if err := AssertLength(args, 1); err != nil {
+				return nil, err
+			}
+			record, ok := args[0].(types.MalRecord)
+			return types.ToMalBool(ok && record.Type == rt), nil
+		},
+	}, nil
+}
+
+func isRecord(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalRecord)
+	return types.ToMalBool(ok), nil
+}
+
+// CallKeyword looks up a keyword in a hash map or a record, e.g., (:x point)
+func CallKeyword(keyword types.MalKeyword, args ...types.MalType) (types.MalType, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("incorrect number of arguments for a keyword: expect 1 or 2 but get %d", len(args))
+	}
+	return get(append([]types.MalType{args[0], keyword}, args[1:]...)...)
+}
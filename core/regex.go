@@ -0,0 +1,188 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"regexp"
+	"strings"
+)
+
+/* Regex functions */
+
+// assertRegexAndString asserts that `args` are a regex and a string
+func assertRegexAndString(args []types.MalType) (*regexp.Regexp, string, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, "", err
+	}
+	re, ok := args[0].(types.MalRegex)
+	if !ok {
+		return nil, "", fmt.Errorf("incorrect arguments type: MalRegex is expected")
+	}
+	s, ok := args[1].(types.MalString)
+	if !ok {
+		return nil, "", fmt.Errorf("incorrect arguments type: MalString is expected")
+	}
+	return re.Value, s.Value, nil
+}
+
+// matchResult converts the submatch indexes of a match to mal, which is the matched string if
+// there is no group, or a vector of the match and all groups (nil for unmatched ones) otherwise
+func matchResult(s string, indexes []int) types.MalType {
+	if len(indexes) == 2 {
+		return types.MalString{Value: s[indexes[0]:indexes[1]]}
+	}
+	groups := make([]types.MalType, 0, len(indexes)/2)
+	for i := 0; i < len(indexes); i += 2 {
+		if indexes[i] < 0 {
+			groups = append(groups, types.MalNil)
+		} else {
+			groups = append(groups, types.MalString{Value: s[indexes[i]:indexes[i+1]]})
+		}
+	}
+	return types.NewVector(groups...)
+}
+
+func rePattern(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if re, ok := args[0].(types.MalRegex); ok {
+		return re, nil
+	}
+	pattern, err := assertOneString(args)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewRegex(pattern)
+}
+
+// reFind returns the first match in the string, or nil if there is none
+func reFind(args ...types.MalType) (types.MalType, error) {
+	re, s, err := assertRegexAndString(args)
+	if err != nil {
+		return nil, err
+	}
+	indexes := re.FindStringSubmatchIndex(s)
+	if indexes == nil {
+		return types.MalNil, nil
+	}
+	return matchResult(s, indexes), nil
+}
+
+// reMatches returns the match if the whole string matches, or nil otherwise
+func reMatches(args ...types.MalType) (types.MalType, error) {
+	_, s, err := assertRegexAndString(args)
+	if err != nil {
+		return nil, err
+	}
+	indexes := args[0].(types.MalRegex).Anchored.FindStringSubmatchIndex(s)
+	if indexes == nil {
+		return types.MalNil, nil
+	}
+	return matchResult(s, indexes), nil
+}
+
+// reSeq returns the list of all matches, or nil if there is none
+func reSeq(args ...types.MalType) (types.MalType, error) {
+	re, s, err := assertRegexAndString(args)
+	if err != nil {
+		return nil, err
+	}
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return types.MalNil, nil
+	}
+	result := make(types.MalList, 0, len(matches))
+	for _, indexes := range matches {
+		result = append(result, matchResult(s, indexes))
+	}
+	return result, nil
+}
+
+// reGroups returns a hash map of the groups in the first match, or nil if there is none
+// Named groups like (?P<year>\d+) are keyed by keywords like :year, and others by their indexes,
+// where 0 is the whole match. Unmatched groups are left out.
+func reGroups(args ...types.MalType) (types.MalType, error) {
+	re, s, err := assertRegexAndString(args)
+	if err != nil {
+		return nil, err
+	}
+	indexes := re.FindStringSubmatchIndex(s)
+	if indexes == nil {
+		return types.MalNil, nil
+	}
+	groups := types.MalHashmap{}
+	for i, name := range re.SubexpNames() {
+		if indexes[2*i] < 0 {
+			continue
+		}
+		var key types.MalType = types.MalNumber{Value: i}
+		if name != "" {
+			key = types.MalKeyword{Value: name}
+		}
+		groups, _ = groups.Assoc(key, types.MalString{Value: s[indexes[2*i]:indexes[2*i+1]]})
+	}
+	return groups, nil
+}
+
+// replace replaces all matches of a string or a regex in a string
+// With a regex, the replacement can be a string with $1 or ${name} for groups, or a function
+// called with each match like re-find returns
+func replace(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 3); err != nil {
+		return nil, err
+	}
+	s, err := assertOneString(args[:1])
+	if err != nil {
+		return nil, err
+	}
+	switch match := args[1].(type) {
+	case types.MalString:
+		replacement, ok := args[2].(types.MalString)
+		if !ok {
+			return nil, fmt.Errorf("incorrect arguments type: MalString is expected")
+		}
+		return types.MalString{Value: strings.ReplaceAll(s, match.Value, replacement.Value)}, nil
+	case types.MalRegex:
+		switch replacement := args[2].(type) {
+		case types.MalString:
+			return types.MalString{Value: match.Value.ReplaceAllString(s, replacement.Value)}, nil
+		default:
+			if !isFunction(replacement) {
+				return nil, fmt.Errorf("the replacement should be a string or a function")
+			}
+			return replaceWithFunction(match.Value, s, replacement)
+		}
+	default:
+		return nil, fmt.Errorf("incorrect arguments type: MalString or MalRegex is expected")
+	}
+}
+
+// replaceWithFunction replaces each match of `re` in `s` by the result of calling `f` with it
+func replaceWithFunction(re *regexp.Regexp, s string, f types.MalType) (types.MalType, error) {
+	var result strings.Builder
+	last := 0
+	for _, indexes := range re.FindAllStringSubmatchIndex(s, -1) {
+		value, err := CallFunction(f, matchResult(s, indexes))
+		if err != nil {
+			return nil, err
+		}
+		str, ok := value.(types.MalString)
+		if !ok {
+			return nil, fmt.Errorf("the replacement function should return a string")
+		}
+		result.WriteString(s[last:indexes[0]])
+		result.WriteString(str.Value)
+		last = indexes[1]
+	}
+	result.WriteString(s[last:])
+	return types.MalString{Value: result.String()}, nil
+}
+
+func isRegex(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalRegex)
+	return types.ToMalBool(ok), nil
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* Sequence functions, most of which return lazy sequences */
+
+// assertNumber asserts that `arg` is a number and returns its value
+func assertNumber(arg types.MalType) (int, error) {
+	n, ok := arg.(types.MalNumber)
+	if !ok {
+		return 0, fmt.Errorf("incorrect arguments type: MalNumber is expected")
+	}
+	return n.Value, nil
+}
+
+// seq returns a list of the elements of a collection, or nil if it's empty
+// A string yields its characters, bytes yield integers, and a hash map or a record yields its entries as
+// [key value] vectors
+func seq(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	var result types.MalList
+	switch t := args[0].(type) {
+	case types.MalString:
+		for _, r := range t.Value {
+			result = append(result, types.MalChar{Value: r})
+		}
+	case types.MalBytes:
+		for _, b := range t.Value {
+			result = append(result, types.MalNumber{Value: int(b)})
+		}
+	case types.MalSet:
+		result = t.Elements()
+	case types.MalHashmap:
+		for _, entry := range t.Entries() {
+			result = append(result, types.NewVector(entry.Key, entry.Value))
+		}
+	case types.MalRecord:
+		for _, entry := range t.Entries() {
+			result = append(result, types.NewVector(entry.Key, entry.Value))
+		}
+	case *types.MalLazySeq: // keep it lazy
+		_, _, ok, err := types.SeqNext(t)
+		if err != nil || !ok {
+			return types.MalNil, err
+		}
+		return t, nil
+	default:
+		lst, err := types.SeqToList(t)
+		if err != nil {
+			return nil, err
+		}
+		result = lst
+	}
+	if len(result) == 0 {
+		return types.MalNil, nil
+	}
+	return result, nil
+}
+
+func first(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	value, _, ok, err := types.SeqNext(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return types.MalNil, nil
+	}
+	return value, nil
+}
+
+func rest(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, remaining, ok, err := types.SeqNext(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return types.MalList{}, nil
+	}
+	return remaining, nil
+}
+
+func cons(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	switch t := args[1].(type) {
+	case *types.MalLazySeq: // keep the rest lazy
+		return types.Cons(args[0], t), nil
+	default:
+		lst, err := types.SeqToList(t)
+		if err != nil {
+			return nil, err
+		}
+		return append(types.MalList{args[0]}, lst...), nil
+	}
+}
+
+// rangeFrom returns the lazy sequence of start, start+step, ... till `end` (exclusive)
+// If `bounded` is false, the sequence is infinite
+func rangeFrom(start, end, step int, bounded bool) types.MalType {
+	return types.NewLazySeq(func() (types.MalType, error) {
+		if bounded && ((step > 0 && start >= end) || (step < 0 && start <= end)) {
+			return types.MalNil, nil
+		}
+		return types.Cons(types.MalNumber{Value: start}, rangeFrom(start+step, end, step, bounded)), nil
+	})
+}
+
+func createRange(args ...types.MalType) (types.MalType, error) {
+	numbers := make([]int, len(args))
+	for i, arg := range args {
+		n, err := assertNumber(arg)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = n
+	}
+	switch len(numbers) {
+	case 0: // (range)
+		return rangeFrom(0, 0, 1, false), nil
+	case 1: // (range end)
+		return rangeFrom(0, numbers[0], 1, true), nil
+	case 2: // (range start end)
+		return rangeFrom(numbers[0], numbers[1], 1, true), nil
+	case 3: // (range start end step)
+		if numbers[2] == 0 { // Clojure returns an infinite sequence, which is hardly useful
+			return nil, fmt.Errorf("step of range can't be zero")
+		}
+		return rangeFrom(numbers[0], numbers[1], numbers[2], true), nil
+	default:
+		return nil, fmt.Errorf("incorrect number of arguments: expect 0 to 3 but get %d", len(args))
+	}
+}
+
+func iterateFrom(f, x types.MalType) types.MalType {
+	return types.Cons(x, types.NewLazySeq(func() (types.MalType, error) {
+		next, err := CallFunction(f, x)
+		if err != nil {
+			return nil, err
+		}
+		return iterateFrom(f, next), nil
+	}))
+}
+
+func iterate(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	return iterateFrom(args[0], args[1]), nil
+}
+
+// repeatForever returns the infinite sequence of `x`, which is its own rest
+func repeatForever(x types.MalType) *types.MalLazySeq {
+	var ls *types.MalLazySeq
+	ls = types.NewLazySeq(func() (types.MalType, error) {
+		return types.Cons(x, ls), nil
+	})
+	return ls
+}
+
+func repeatValue(args ...types.MalType) (types.MalType, error) {
+	switch len(args) {
+	case 1: // (repeat x)
+		return repeatForever(args[0]), nil
+	case 2: // (repeat n x)
+		n, err := assertNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return takeFrom(n, repeatForever(args[1])), nil
+	default:
+		return nil, fmt.Errorf("incorrect number of arguments: expect 1 or 2 but get %d", len(args))
+	}
+}
+
+func cycleFrom(coll, seq types.MalType) types.MalType {
+	return types.NewLazySeq(func() (types.MalType, error) {
+		value, remaining, ok, err := types.SeqNext(seq)
+		if err != nil {
+			return nil, err
+		}
+		if !ok { // start over again
+			if value, remaining, ok, err = types.SeqNext(coll); err != nil || !ok {
+				return types.MalNil, err
+			}
+		}
+		return types.Cons(value, cycleFrom(coll, remaining)), nil
+	})
+}
+
+func cycle(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	return cycleFrom(args[0], args[0]), nil
+}
+
+func takeFrom(n int, seq types.MalType) types.MalType {
+	return types.NewLazySeq(func() (types.MalType, error) {
+		if n <= 0 {
+			return types.MalNil, nil
+		}
+		value, remaining, ok, err := types.SeqNext(seq)
+		if err != nil || !ok {
+			return types.MalNil, err
+		}
+		return types.Cons(value, takeFrom(n-1, remaining)), nil
+	})
+}
+
+func take(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	n, err := assertNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return takeFrom(n, args[1]), nil
+}
+
+func drop(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	n, err := assertNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return types.NewLazySeq(func() (types.MalType, error) {
+		seq := args[1] // a local cursor, so that a failed realization can be retried from the start
+		for i := 0; i < n; i++ {
+			_, remaining, ok, err := types.SeqNext(seq)
+			if err != nil || !ok {
+				return types.MalNil, err
+			}
+			seq = remaining
+		}
+		return seq, nil
+	}), nil
+}
+
+func takeWhileFrom(pred, seq types.MalType) types.MalType {
+	return types.NewLazySeq(func() (types.MalType, error) {
+		value, remaining, ok, err := types.SeqNext(seq)
+		if err != nil || !ok {
+			return types.MalNil, err
+		}
+		result, err := CallFunction(pred, value)
+		if err != nil {
+			return nil, err
+		}
+		if result == types.MalFalse || result == types.MalNil {
+			return types.MalNil, nil
+		}
+		return types.Cons(value, takeWhileFrom(pred, remaining)), nil
+	})
+}
+
+func takeWhile(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	return takeWhileFrom(args[0], args[1]), nil
+}
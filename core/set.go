@@ -0,0 +1,202 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* Set functions */
+
+// assertSets asserts that `args` are all sets, and at least `min` of them
+func assertSets(args []types.MalType, min int) ([]types.MalSet, error) {
+	if len(args) < min {
+		return nil, fmt.Errorf("incorrect number of arguments: expect at least %d but get %d", min, len(args))
+	}
+	sets := make([]types.MalSet, 0, len(args))
+	for _, arg := range args {
+		set, ok := arg.(types.MalSet)
+		if !ok {
+			return nil, fmt.Errorf("incorrect arguments type: MalSet is expected")
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+func createSet(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if set, ok := args[0].(types.MalSet); ok {
+		return set, nil
+	}
+	elements, err := types.SeqToList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return types.NewSet(elements...)
+}
+
+func isSet(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalSet)
+	return types.ToMalBool(ok), nil
+}
+
+// assertMapEntry asserts that `entry` is a [key value] vector to be added to a map, and returns its
+// key and value
+func assertMapEntry(entry types.MalType) (types.MalType, types.MalType, error) {
+	v, ok := entry.(types.MalVector)
+	if !ok || v.Count() != 2 {
+		return nil, nil, fmt.Errorf("only [key value] vectors can be conj'ed to a map")
+	}
+	key, _ := v.Nth(0)
+	value, _ := v.Nth(1)
+	return key, value, nil
+}
+
+// conj adds elements to a collection in the most efficient way for it, i.e.,
+// at the beginning of a list, at the end of a vector, or anywhere in a set
+// The elements added to a hash map or a record are [key value] vectors.
+func conj(args ...types.MalType) (types.MalType, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect at least 1 but get 0")
+	}
+	values := args[1:]
+	switch coll := args[0].(type) {
+	case types.MalSet:
+		return coll.Add(values...)
+	case types.MalVector:
+		for _, v := range values {
+			coll = coll.Conj(v)
+		}
+		return coll, nil
+	case types.MalList:
+		result := make(types.MalList, 0, len(coll)+len(values))
+		for i := len(values) - 1; i >= 0; i-- {
+			result = append(result, values[i])
+		}
+		return append(result, coll...), nil
+	case types.MalHashmap:
+		for _, v := range values {
+			key, value, err := assertMapEntry(v)
+			if err != nil {
+				return nil, err
+			}
+			if coll, err = coll.Assoc(key, value); err != nil {
+				return nil, err
+			}
+		}
+		return coll, nil
+	case types.MalRecord:
+		for _, v := range values {
+			key, value, err := assertMapEntry(v)
+			if err != nil {
+				return nil, err
+			}
+			if coll, err = coll.Assoc(key, value); err != nil {
+				return nil, err
+			}
+		}
+		return coll, nil
+	case types.MalLiteral:
+		if coll == types.MalNil {
+			return conj(append([]types.MalType{types.MalList{}}, values...)...)
+		}
+	}
+	return nil, fmt.Errorf("can't conj to a non-collection")
+}
+
+func disj(args ...types.MalType) (types.MalType, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect at least 1 but get 0")
+	}
+	sets, err := assertSets(args[:1], 1)
+	if err != nil {
+		return nil, err
+	}
+	return sets[0].Remove(args[1:]...), nil
+}
+
+// contains checks whether a set has a value, a hash map or a record has a key, or a vector has an index
+func contains(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	switch coll := args[0].(type) {
+	case types.MalSet:
+		return types.ToMalBool(coll.Contains(args[1])), nil
+	case types.MalHashmap:
+		_, ok := coll.Get(args[1])
+		return types.ToMalBool(ok), nil
+	case types.MalRecord:
+		_, ok := coll.Get(args[1])
+		return types.ToMalBool(ok), nil
+	case types.MalVector:
+		i, ok := args[1].(types.MalNumber)
+		return types.ToMalBool(ok && i.Value >= 0 && i.Value < coll.Count()), nil
+	default:
+		return nil, fmt.Errorf("contains? is only supported for sets, hash maps, records and vectors")
+	}
+}
+
+func union(args ...types.MalType) (types.MalType, error) {
+	sets, err := assertSets(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	result := sets[0]
+	for _, set := range sets[1:] {
+		if result, err = result.Add(set.Elements()...); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func intersection(args ...types.MalType) (types.MalType, error) {
+	sets, err := assertSets(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	result := sets[0]
+	for _, v := range sets[0].Elements() {
+		for _, other := range sets[1:] {
+			if !other.Contains(v) {
+				result = result.Remove(v)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func difference(args ...types.MalType) (types.MalType, error) {
+	sets, err := assertSets(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	result := sets[0]
+	for _, other := range sets[1:] {
+		result = result.Remove(other.Elements()...)
+	}
+	return result, nil
+}
+
+func isSubset(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	sets, err := assertSets(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range sets[0].Elements() {
+		if !sets[1].Contains(v) {
+			return types.MalFalse, nil
+		}
+	}
+	return types.MalTrue, nil
+}
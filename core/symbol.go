@@ -0,0 +1,31 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"sync/atomic"
+)
+
+/* Symbol functions */
+
+// gensymCounter makes the symbols created by gensym unique
+var gensymCounter int64
+
+// gensym creates a unique symbol like G__42, or prefix42 with a prefix
+func gensym(args ...types.MalType) (types.MalType, error) {
+	prefix := "G__"
+	if len(args) > 0 {
+		str, err := assertOneString(args)
+		if err != nil {
+			return nil, err
+		}
+		prefix = str
+	}
+	return Gensym(prefix), nil
+}
+
+// Gensym creates a unique symbol by appending a number to `prefix`
+func Gensym(prefix string) types.MalSymbol {
+	id := atomic.AddInt64(&gensymCounter, 1)
+	return types.MalSymbol{Value: fmt.Sprintf("%s%d", prefix, id)}
+}
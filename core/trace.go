@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/printer"
+	"github.com/keithnull/mal-go/types"
+	"io"
+	"os"
+	"strings"
+)
+
+/* Function call tracing */
+
+// TraceOutput is where the calls of traced functions are printed, which is set by trace-output in mal
+var TraceOutput io.Writer = os.Stdout
+
+// TraceMaxDepth is the maximum depth of nested traced calls to print, and 0 means no limit
+var TraceMaxDepth = 0
+
+// traceNestingLimit is the maximum depth of nested traced calls
+// A traced function is called by its wrapper rather than in the TCO loop of EVAL, so its tail calls
+// take up the stack, and deep recursion would overflow it without the limit.
+const traceNestingLimit = 10000
+
+// traceDepth is the depth of the traced calls being evaluated
+var traceDepth = 0
+
+// traceTarget is what trace-output is set to, and traceFile is the file opened for it if any
+var (
+	traceTarget types.MalType = types.MalKeyword{Value: "stdout"}
+	traceFile   *os.File
+)
+
+// Trace wraps `f` so that each call of it prints the arguments and the return value
+// Like Racket, calls are prefixed by ">" and returns by "<", both repeated by the depth
+// The original function is kept in the wrapper to be restored by Untrace()
+// Tail calls of a traced function aren't optimized, so its nesting is limited by traceNestingLimit.
+func Trace(name string, f types.MalType) (types.MalType, error) {
+	switch f.(type) {
+	case types.MalFunction, types.MalFunctionTCO:
+	case *types.MalTracedFn: // don't wrap it twice
+		return nil, fmt.Errorf("'%s' is already traced", name)
+	default:
+		return nil, fmt.Errorf("can't trace '%s' which is not a function", name)
+	}
+	wrapper := func(args ...types.MalType) (types.MalType, error) {
+		traceDepth++
+		defer func() { traceDepth-- }()
+		if traceDepth > traceNestingLimit {
+			return nil, fmt.Errorf("traced calls nested too deeply (over %d), as tail calls aren't optimized while tracing", traceNestingLimit)
+		}
+		visible := TraceMaxDepth <= 0 || traceDepth <= TraceMaxDepth
+		if visible {
+			call := append(types.MalList{types.MalSymbol{Value: name}}, args...)
+			fmt.Fprintf(TraceOutput, "%s>%s\n", strings.Repeat("> ", traceDepth-1), printer.PrintStr(call, true))
+		}
+		result, err := CallFunction(f, args...)
+		if visible {
+			indent := strings.Repeat("< ", traceDepth-1)
+			if err != nil {
+				fmt.Fprintf(TraceOutput, "%s<error: %v\n", indent, err)
+			} else {
+				fmt.Fprintf(TraceOutput, "%s<%s\n", indent, printer.PrintStr(result, true))
+			}
+		}
+		return result, err
+	}
+	return &types.MalTracedFn{Name: name, Original: f, Function: wrapper}, nil
+}
+
+// Untrace returns the original function of `f`, which is what `name` is bound to
+// It fails if `f` isn't traced, e.g., `name` has been defined again since it was traced
+func Untrace(name string, f types.MalType) (types.MalType, error) {
+	traced, ok := f.(*types.MalTracedFn)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not traced", name)
+	}
+	return traced.Original, nil
+}
+
+func setTraceDepth(args ...types.MalType) (types.MalType, error) {
+	if len(args) > 0 {
+		if err := AssertLength(args, 1); err != nil {
+			return nil, err
+		}
+		depth, err := assertNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		TraceMaxDepth = depth
+	}
+	return types.MalNumber{Value: TraceMaxDepth}, nil
+}
+
+// setTraceOutput is the mal function `trace-output`, which sets where traced calls are printed to
+// :stdout, :stderr or a new file at a path, and returns the current setting
+func setTraceOutput(args ...types.MalType) (types.MalType, error) {
+	if len(args) == 0 {
+		return traceTarget, nil
+	}
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	var output io.Writer
+	var file *os.File
+	switch t := args[0].(type) {
+	case types.MalKeyword:
+		switch t.Value {
+		case "stdout":
+			output = os.Stdout
+		case "stderr":
+			output = os.Stderr
+		default:
+			return nil, fmt.Errorf("unknown trace output: :%s", t.Value)
+		}
+	case types.MalString:
+		var err error
+		if file, err = os.Create(t.Value); err != nil {
+			return nil, err
+		}
+		output = file
+	default:
+		return nil, fmt.Errorf("incorrect arguments type: keyword or string is expected")
+	}
+	if traceFile != nil {
+		_ = traceFile.Close()
+	}
+	TraceOutput, traceTarget, traceFile = output, args[0], file
+	return traceTarget, nil
+}
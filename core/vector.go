@@ -0,0 +1,97 @@
+package core
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+)
+
+/* Vector functions */
+
+func createVector(args ...types.MalType) (types.MalType, error) {
+	return types.NewVector(args...), nil
+}
+
+func toVector(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	if vec, ok := args[0].(types.MalVector); ok {
+		return vec, nil
+	}
+	elements, err := types.SeqToList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return types.NewVector(elements...), nil
+}
+
+func isVector(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(types.MalVector)
+	return types.ToMalBool(ok), nil
+}
+
+func nth(args ...types.MalType) (types.MalType, error) {
+	if err := AssertLength(args, 2); err != nil {
+		return nil, err
+	}
+	index, err := assertNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	switch coll := args[0].(type) {
+	case types.MalVector:
+		return coll.Nth(index)
+	case types.MalList:
+		if index < 0 || index >= len(coll) {
+			return nil, fmt.Errorf("index %d out of bounds for a list of %d element(s)", index, len(coll))
+		}
+		return coll[index], nil
+	case types.MalBytes:
+		if index < 0 || index >= len(coll.Value) {
+			return nil, fmt.Errorf("index %d out of bounds for %d byte(s)", index, len(coll.Value))
+		}
+		return types.MalNumber{Value: int(coll.Value[index])}, nil
+	default:
+		return nil, fmt.Errorf("can't get the nth element of a non-vector")
+	}
+}
+
+func assoc(args ...types.MalType) (types.MalType, error) {
+	if len(args) < 3 || len(args)%2 != 1 {
+		return nil, fmt.Errorf("incorrect number of arguments: expect a collection and key-value pairs")
+	}
+	switch coll := args[0].(type) {
+	case types.MalVector:
+		for i := 1; i < len(args); i += 2 {
+			index, err := assertNumber(args[i])
+			if err != nil {
+				return nil, err
+			}
+			if coll, err = coll.Assoc(index, args[i+1]); err != nil {
+				return nil, err
+			}
+		}
+		return coll, nil
+	case types.MalHashmap:
+		for i := 1; i < len(args); i += 2 {
+			var err error
+			if coll, err = coll.Assoc(args[i], args[i+1]); err != nil {
+				return nil, err
+			}
+		}
+		return coll, nil
+	case types.MalRecord:
+		for i := 1; i < len(args); i += 2 {
+			var err error
+			if coll, err = coll.Assoc(args[i], args[i+1]); err != nil {
+				return nil, err
+			}
+		}
+		return coll, nil
+	default:
+		return nil, fmt.Errorf("can't assoc to a non-vector or non-hashmap")
+	}
+}
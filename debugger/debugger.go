@@ -0,0 +1,281 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/keithnull/mal-go/core"
+	"github.com/keithnull/mal-go/environment"
+	"github.com/keithnull/mal-go/printer"
+	"github.com/keithnull/mal-go/reader"
+	"github.com/keithnull/mal-go/types"
+	"io"
+	"sort"
+	"strings"
+)
+
+// PromptFunc prints a prompt and reads one line of input
+type PromptFunc func(prompt string) (string, error)
+
+// ErrAborted is returned to abort the evaluation from the debugger
+var ErrAborted = errors.New("evaluation aborted by debugger")
+
+const prompt = "debug> "
+
+const help = `Commands:
+  :step, :s      evaluate till the next form
+  :next, :n      evaluate till the next form not deeper than the current one
+  :continue, :c  evaluate till the next breakpoint
+  :where, :w     print the current form
+  :locals, :l    print the bindings of the current environment
+  :env, :e       print the bindings of all environments except the outermost one
+  :backtrace, :bt
+                 print the forms being evaluated, the innermost one first
+  :abort, :q     abort the evaluation
+  :help, :h      print this message
+Any other input is evaluated within the current environment.`
+
+// mode tells when the debugger should pause next time
+type mode int
+
+const (
+	running  mode = iota // only at breakpoints
+	stepping             // at any form
+	stepOver             // at any form not deeper than `stopDepth`
+)
+
+// frame is a form being evaluated by an EVAL call, together with its environment
+// As EVAL does TCO, the form and environment are updated for each tail call
+type frame struct {
+	ast types.MalType
+	env types.MalEnv
+}
+
+// Debugger keeps track of the forms being evaluated so that EVAL can be paused
+// to inspect them and their environments in a sub-REPL
+// All methods are safe to call with a nil Debugger, doing nothing
+type Debugger struct {
+	eval        types.EvalFunc
+	prompt      PromptFunc
+	out         io.Writer
+	frames      []frame
+	breakpoints map[string]bool
+	onError     bool
+	mode        mode
+	stopDepth   int
+	reported    error // the error the debugger has paused for, not to pause again while unwinding
+	paused      bool  // to avoid nested pausing caused by evaluating input in the sub-REPL
+}
+
+// New creates a debugger, which evaluates the sub-REPL input with `eval`,
+// reads input with `prompt` and writes output to `out`
+func New(eval types.EvalFunc, prompt PromptFunc, out io.Writer) *Debugger {
+	return &Debugger{
+		eval:        eval,
+		prompt:      prompt,
+		out:         out,
+		breakpoints: make(map[string]bool),
+	}
+}
+
+// Push is called when EVAL starts evaluating `ast` within `env`
+func (d *Debugger) Push(ast types.MalType, env types.MalEnv) {
+	if d == nil {
+		return
+	}
+	d.frames = append(d.frames, frame{ast, env})
+}
+
+// Pop is called when EVAL returns with `err`, which will be returned as is
+// if debugging on errors is enabled, the debugger pauses at the innermost frame seeing the error
+func (d *Debugger) Pop(err error) error {
+	if d == nil {
+		return err
+	}
+	if err != nil && d.onError && !d.paused && !core.IsEscape(err) && !errors.Is(err, ErrAborted) &&
+		(d.reported == nil || !errors.Is(err, d.reported)) {
+		d.reported = err
+		if pauseErr := d.pause("error: " + err.Error()); pauseErr != nil {
+			err = pauseErr
+		}
+	}
+	d.frames = d.frames[:len(d.frames)-1]
+	if len(d.frames) == 0 { // back to the top level
+		d.reported, d.mode = nil, running
+	}
+	return err
+}
+
+// Step is called by EVAL before evaluating each form (including tail calls)
+// It pauses if stepping or a breakpoint is hit. If the user aborts, an error is returned.
+func (d *Debugger) Step(ast types.MalType, env types.MalEnv) error {
+	if d == nil {
+		return nil
+	}
+	top := &d.frames[len(d.frames)-1]
+	top.ast, top.env = ast, env
+	if d.paused || (d.mode == running && len(d.breakpoints) == 0) {
+		return nil
+	}
+	if d.mode == stepping || (d.mode == stepOver && len(d.frames) <= d.stopDepth) {
+		return d.pause("step")
+	}
+	if lst, ok := ast.(types.MalList); ok && len(lst) > 0 {
+		if symbol, ok := lst[0].(types.MalSymbol); ok && d.breakpoints[symbol.Value] {
+			return d.pause("breakpoint " + symbol.Value)
+		}
+	}
+	return nil
+}
+
+// Break pauses the evaluation at `(break)` within `env`
+func (d *Debugger) Break(ast types.MalType, env types.MalEnv) error {
+	if d == nil || d.paused {
+		return nil
+	}
+	top := &d.frames[len(d.frames)-1]
+	top.ast, top.env = ast, env
+	return d.pause("break")
+}
+
+// pause runs the sub-REPL at the innermost frame till the user resumes or aborts the evaluation
+func (d *Debugger) pause(reason string) error {
+	d.paused = true
+	defer func() { d.paused = false }()
+	current := d.frames[len(d.frames)-1]
+	fmt.Fprintf(d.out, "[debug] %s: %s\n", reason, printer.PrintStr(current.ast, true))
+	for {
+		input, err := d.prompt(prompt)
+		if err != nil { // EOF, so just resume
+			d.mode = running
+			return nil
+		}
+		switch strings.TrimSpace(input) {
+		case "":
+		case ":step", ":s":
+			d.mode = stepping
+			return nil
+		case ":next", ":n":
+			d.mode, d.stopDepth = stepOver, len(d.frames)
+			return nil
+		case ":continue", ":c":
+			d.mode = running
+			return nil
+		case ":abort", ":q":
+			d.mode = running
+			return ErrAborted
+		case ":where", ":w":
+			fmt.Fprintln(d.out, printer.PrintStr(current.ast, true))
+		case ":locals", ":l":
+			d.printBindings(current.env)
+		case ":env", ":e":
+			for env, level := current.env, 0; env != nil; level++ {
+				e, ok := env.(*environment.Env)
+				if !ok || e.Outer() == nil { // skip the outermost one, which has all builtins
+					break
+				}
+				fmt.Fprintf(d.out, "level %d:\n", level)
+				d.printBindings(e)
+				env = e.Outer()
+			}
+		case ":backtrace", ":bt":
+			for i := len(d.frames) - 1; i >= 0; i-- {
+				fmt.Fprintf(d.out, "#%d %s\n", len(d.frames)-1-i, printer.PrintStr(d.frames[i].ast, true))
+			}
+		case ":help", ":h":
+			fmt.Fprintln(d.out, help)
+		default:
+			ast, err := reader.ReadStr(input)
+			if err == nil {
+				ast, err = d.eval(ast, current.env)
+			}
+			if err != nil {
+				fmt.Fprintln(d.out, err)
+			} else {
+				fmt.Fprintln(d.out, printer.PrintStr(ast, true))
+			}
+		}
+	}
+}
+
+func (d *Debugger) printBindings(env types.MalEnv) {
+	e, ok := env.(*environment.Env)
+	if !ok {
+		return
+	}
+	for _, symbol := range e.Symbols() {
+		value, _ := e.Get(types.MalSymbol{Value: symbol})
+		fmt.Fprintf(d.out, "  %s = %s\n", symbol, printer.PrintStr(value, true))
+	}
+}
+
+// Functions returns the debugging functions to be added to the environment
+func (d *Debugger) Functions() map[string]types.MalFunction {
+	return map[string]types.MalFunction{
+		"break-on":       d.breakOn,
+		"break-off":      d.breakOff,
+		"breakpoints":    d.listBreakpoints,
+		"debug-on-error": d.debugOnError,
+	}
+}
+
+// assertNames asserts that `args` are all strings, i.e., function names
+func assertNames(args []types.MalType) ([]string, error) {
+	names := make([]string, 0, len(args))
+	for _, arg := range args {
+		name, ok := arg.(types.MalString)
+		if !ok {
+			return nil, fmt.Errorf("incorrect arguments type: MalString is expected")
+		}
+		names = append(names, name.Value)
+	}
+	return names, nil
+}
+
+func (d *Debugger) breakOn(args ...types.MalType) (types.MalType, error) {
+	names, err := assertNames(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		d.breakpoints[name] = true
+	}
+	return d.listBreakpoints()
+}
+
+func (d *Debugger) breakOff(args ...types.MalType) (types.MalType, error) {
+	names, err := assertNames(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 { // remove all
+		d.breakpoints = make(map[string]bool)
+	}
+	for _, name := range names {
+		delete(d.breakpoints, name)
+	}
+	return d.listBreakpoints()
+}
+
+func (d *Debugger) listBreakpoints(args ...types.MalType) (types.MalType, error) {
+	if err := core.AssertLength(args, 0); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(d.breakpoints))
+	for name := range d.breakpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := types.MalList{}
+	for _, name := range names {
+		result = append(result, types.MalString{Value: name})
+	}
+	return result, nil
+}
+
+func (d *Debugger) debugOnError(args ...types.MalType) (types.MalType, error) {
+	if err := core.AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	d.onError = args[0] != types.MalFalse && args[0] != types.MalNil
+	return types.ToMalBool(d.onError), nil
+}
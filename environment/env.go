@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/keithnull/mal-go/core"
 	"github.com/keithnull/mal-go/types"
+	"sort"
 )
 
 // Env implements types.MalEnv interface
@@ -107,3 +108,24 @@ func GetInitEnv() (e *Env) {
 	}
 	return
 }
+
+// Outer returns the outer environment, which is nil for the outermost one
+func (e *Env) Outer() types.MalEnv {
+	if e == nil {
+		return nil
+	}
+	return e.outer
+}
+
+// Symbols returns the sorted names of all symbols bound in this environment (excluding outer ones)
+func (e *Env) Symbols() []string {
+	if e == nil {
+		return nil
+	}
+	symbols := make([]string, 0, len(e.data))
+	for k := range e.data {
+		symbols = append(symbols, k)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
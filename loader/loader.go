@@ -0,0 +1,156 @@
+package loader
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/core"
+	"github.com/keithnull/mal-go/reader"
+	"github.com/keithnull/mal-go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extension is appended to module names that don't have one
+const Extension = ".mal"
+
+// PathEnv is the name of the environment variable holding the module search path
+const PathEnv = "MAL_PATH"
+
+// Loader loads mal source files into an environment
+// Modules loaded with Require() are cached so that each of them is evaluated only once,
+// while LoadFile() evaluates the given file every time it is called
+type Loader struct {
+	env     types.MalEnv
+	eval    types.EvalFunc
+	paths   []string        // search path, taken from MAL_PATH
+	loaded  map[string]bool // absolute paths of the modules loaded by Require()
+	loading []string        // files being loaded, the innermost one at the end
+}
+
+// New creates a loader evaluating files within `env` with `eval`
+// The search path is initialized from the MAL_PATH environment variable
+func New(env types.MalEnv, eval types.EvalFunc) *Loader {
+	ld := &Loader{
+		env:    env,
+		eval:   eval,
+		loaded: make(map[string]bool),
+	}
+	for _, dir := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if dir != "" {
+			ld.paths = append(ld.paths, dir)
+		}
+	}
+	return ld
+}
+
+// SearchPath returns the directories where a module is looked up, in order:
+// the directory of the file being loaded (or the current directory), then MAL_PATH
+func (ld *Loader) SearchPath() []string {
+	current := "."
+	if n := len(ld.loading); n > 0 {
+		current = filepath.Dir(ld.loading[n-1])
+	}
+	return append([]string{current}, ld.paths...)
+}
+
+// Resolve finds the file for module `name` in the search path
+func (ld *Loader) Resolve(name string) (string, error) {
+	candidates := []string{name}
+	if filepath.Ext(name) == "" {
+		candidates = append(candidates, name+Extension)
+	}
+	dirs := ld.SearchPath()
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	}
+	for _, dir := range dirs {
+		for _, candidate := range candidates {
+			path := filepath.Join(dir, candidate)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("module '%s' not found in %s", name, strings.Join(dirs, string(filepath.ListSeparator)))
+}
+
+// load reads the file at `path` and evaluates all forms in it, in order
+// Circular loads are detected with the stack of files being loaded
+func (ld *Loader) load(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for i, loading := range ld.loading {
+		if other, _ := filepath.Abs(loading); other == abs {
+			cycle := append(append([]string{}, ld.loading[i:]...), path)
+			return fmt.Errorf("circular load: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	ld.loading = append(ld.loading, path)
+	defer func() { ld.loading = ld.loading[:len(ld.loading)-1] }()
+	// forms are read and evaluated one by one, so the file is never read as a whole
+	fr := reader.NewFormReader(file)
+	for {
+		ast, err := fr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err == nil {
+			_, err = ld.eval(ast, ld.env)
+		}
+		if err != nil {
+			return fmt.Errorf("error loading '%s': %w", path, err)
+		}
+	}
+}
+
+// LoadFile is the mal function `load-file`
+// It evaluates the given file (relative to the current directory) every time
+func (ld *Loader) LoadFile(args ...types.MalType) (types.MalType, error) {
+	if err := core.AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	path, ok := args[0].(types.MalString)
+	if !ok {
+		return nil, fmt.Errorf("incorrect arguments type: MalString is expected")
+	}
+	if err := ld.load(path.Value); err != nil {
+		return nil, err
+	}
+	return types.MalNil, nil
+}
+
+// Require is the mal function `require`
+// It looks the module up in the search path and evaluates it unless it has been loaded
+func (ld *Loader) Require(args ...types.MalType) (types.MalType, error) {
+	if err := core.AssertLength(args, 1); err != nil {
+		return nil, err
+	}
+	name, ok := args[0].(types.MalString)
+	if !ok {
+		return nil, fmt.Errorf("incorrect arguments type: MalString is expected")
+	}
+	path, err := ld.Resolve(name.Value)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if ld.loaded[abs] {
+		return types.MalNil, nil
+	}
+	if err := ld.load(path); err != nil {
+		return nil, err
+	}
+	ld.loaded[abs] = true
+	return types.MalNil, nil
+}
@@ -3,13 +3,21 @@ package main
 import (
 	"fmt"
 	"github.com/keithnull/mal-go/core"
+	"github.com/keithnull/mal-go/debugger"
 	"github.com/keithnull/mal-go/environment"
+	"github.com/keithnull/mal-go/loader"
 	"github.com/keithnull/mal-go/printer"
 	"github.com/keithnull/mal-go/reader"
 	"github.com/keithnull/mal-go/readline"
 	. "github.com/keithnull/mal-go/types" // not recommended but convenient
+	"io/ioutil"
+	"os"
+	"strings"
 )
 
+// dbg is the debugger hooked into EVAL, which is nil (i.e., disabled) till the REPL starts
+var dbg *debugger.Debugger
+
 func READ(in string) (MalType, error) {
 	ast, err := reader.ReadStr(in)
 	if err != nil {
@@ -37,31 +45,136 @@ func evalAST(ast MalType, env MalEnv) (MalType, error) {
 		}
 		return evaluatedList, nil
 	case MalVector:
-		evaluatedList, err := evalAST(MalList(t), env)
+		evaluatedList, err := evalAST(MalList(t.Slice()), env)
 		if err != nil {
 			return nil, err
 		}
-		return MalVector(evaluatedList.(MalList)), nil
+		return NewVector(evaluatedList.(MalList)...), nil
 	case MalHashmap:
-		result := make(MalHashmap)
-		for k, v := range t {
-			v, err := EVAL(v, env)
-			if err != nil {
-				return nil, err
+		kvs := make(MalList, 0, 2*t.Count())
+		for _, entry := range t.Entries() {
+			kvs = append(kvs, entry.Key, entry.Value)
+		}
+		evaluatedList, err := evalAST(kvs, env)
+		if err != nil {
+			return nil, err
+		}
+		return NewHashmap(evaluatedList.(MalList)...)
+	case MalSet:
+		evaluatedList, err := evalAST(MalList(t.Elements()), env)
+		if err != nil {
+			return nil, err
+		}
+		return NewSet(evaluatedList.(MalList)...)
+	default:
+		return ast, nil
+	}
+}
+
+// isForm tells whether `ast` is a list like (name x)
+func isForm(ast MalList, name string) bool {
+	if len(ast) == 0 {
+		return false
+	}
+	symbol, ok := ast[0].(MalSymbol)
+	return ok && symbol.Value == name
+}
+
+// syntaxQuote evaluates the template `ast` of syntax-quote, where (unquote x) is replaced by the
+// value of x, (splice-unquote x) by the elements of it, and any other symbol is kept as it is,
+// except that each foo# is replaced by the symbol generated for it in `gensyms`
+func syntaxQuote(ast MalType, env MalEnv, gensyms map[string]MalSymbol) (MalType, error) {
+	switch t := ast.(type) {
+	case MalSymbol:
+		if len(t.Value) < 2 || !strings.HasSuffix(t.Value, "#") {
+			return t, nil
+		}
+		name := strings.TrimSuffix(t.Value, "#")
+		if _, ok := gensyms[name]; !ok {
+			symbol := core.Gensym(name + "__")
+			gensyms[name] = MalSymbol{Value: symbol.Value + "__auto__"}
+		}
+		return gensyms[name], nil
+	case MalList:
+		if isForm(t, "unquote") {
+			if len(t) != 2 {
+				return nil, fmt.Errorf("incorrect number of arguments for 'unquote'")
 			}
-			result[k] = v
+			return EVAL(t[1], env)
+		}
+		if isForm(t, "splice-unquote") {
+			return nil, fmt.Errorf("splice-unquote is only allowed in a collection")
+		}
+		return syntaxQuoteElements(t, env, gensyms)
+	case MalVector:
+		elements, err := syntaxQuoteElements(t.Slice(), env, gensyms)
+		if err != nil {
+			return nil, err
+		}
+		return NewVector(elements...), nil
+	case MalHashmap:
+		kvs := make(MalList, 0, 2*t.Count())
+		for _, entry := range t.Entries() {
+			kvs = append(kvs, entry.Key, entry.Value)
+		}
+		evaluated, err := syntaxQuoteElements(kvs, env, gensyms)
+		if err != nil {
+			return nil, err
+		}
+		if len(evaluated)%2 != 0 {
+			return nil, fmt.Errorf("incorrect number of elements for a hashmap")
+		}
+		return NewHashmap(evaluated...)
+	case MalSet:
+		elements, err := syntaxQuoteElements(t.Elements(), env, gensyms)
+		if err != nil {
+			return nil, err
 		}
-		return result, nil
+		return NewSet(elements...)
 	default:
 		return ast, nil
 	}
 }
 
+// syntaxQuoteElements evaluates the elements of a collection in a template, splicing the values
+// of (splice-unquote x)
+func syntaxQuoteElements(elements []MalType, env MalEnv, gensyms map[string]MalSymbol) (MalList, error) {
+	result := make(MalList, 0, len(elements))
+	for _, element := range elements {
+		if lst, ok := element.(MalList); ok && isForm(lst, "splice-unquote") {
+			if len(lst) != 2 {
+				return nil, fmt.Errorf("incorrect number of arguments for 'splice-unquote'")
+			}
+			value, err := EVAL(lst[1], env)
+			if err != nil {
+				return nil, err
+			}
+			spliced, err := SeqToList(value)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, spliced...)
+			continue
+		}
+		value, err := syntaxQuote(element, env, gensyms)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
 // EVAL evaluates `ast` within `env` environment
 // If any error occurs, the result will be `nil`
-func EVAL(ast MalType, env MalEnv) (MalType, error) {
+func EVAL(ast MalType, env MalEnv) (result MalType, err error) {
+	dbg.Push(ast, env)
+	defer func() { err = dbg.Pop(err) }()
 	// infinite loop for tail call optimization (TCO)
 	for {
+		if err := dbg.Step(ast, env); err != nil {
+			return nil, err
+		}
 		// only MalList is handled here, other types will be passed to evalAST() directly
 		switch t := ast.(type) {
 		case MalList:
@@ -136,6 +249,119 @@ func EVAL(ast MalType, env MalEnv) (MalType, error) {
 				if condition == MalFalse || condition == MalNil { // False
 					ast = t[3]
 				}
+			case "trace", "untrace": // (trace f g ...), where f, g are names of functions
+				for _, name := range t[1:] {
+					symbol, ok := name.(MalSymbol)
+					if !ok {
+						return nil, fmt.Errorf("the parameters are expected to be symbols")
+					}
+					targetEnv := env.Find(symbol)
+					if targetEnv == nil {
+						return nil, fmt.Errorf("failed to look up '%s' in environments", symbol.Value)
+					}
+					current, _ := targetEnv.Get(symbol)
+					var f MalType
+					var err error
+					if first == "trace" {
+						f, err = core.Trace(symbol.Value, current)
+					} else {
+						f, err = core.Untrace(symbol.Value, current)
+					}
+					if err != nil {
+						return nil, err
+					}
+					if err = targetEnv.Set(symbol, f); err != nil {
+						return nil, err
+					}
+				}
+				return MalNil, nil
+			case "defrecord": // (defrecord Point [x y])
+				if len(t) != 3 {
+					return nil, fmt.Errorf("incorrect number of arguments for 'defrecord'")
+				}
+				name, ok := t[1].(MalSymbol)
+				if !ok {
+					return nil, fmt.Errorf("the first parameter is expected to be a symbol")
+				}
+				functions, err := core.DefineRecord(name.Value, t[2])
+				if err != nil {
+					return nil, err
+				}
+				for fname, f := range functions {
+					if err := env.Set(MalSymbol{Value: fname}, f); err != nil {
+						return nil, err
+					}
+				}
+				return name, nil
+			case "defmulti": // (defmulti area dispatch-fn)
+				if len(t) != 3 {
+					return nil, fmt.Errorf("incorrect number of arguments for 'defmulti'")
+				}
+				name, ok := t[1].(MalSymbol)
+				if !ok {
+					return nil, fmt.Errorf("the first parameter is expected to be a symbol")
+				}
+				// like Clojure, defining it again does nothing, so reloading a file keeps its methods
+				// only a multimethod in this very environment counts, rather than one in an outer one
+				if env.Find(name) == env {
+					existing, _ := env.Get(name)
+					if multi, ok := existing.(*MalMultiFn); ok {
+						return multi, nil
+					}
+				}
+				dispatch, err := EVAL(t[2], env)
+				if err != nil {
+					return nil, err
+				}
+				multi, err := core.NewMultiFn(name.Value, dispatch)
+				if err != nil {
+					return nil, err
+				}
+				return multi, env.Set(name, multi)
+			case "defmethod": // (defmethod area :square (s) body), where the method is like fn*
+				if len(t) != 5 {
+					return nil, fmt.Errorf("incorrect number of arguments for 'defmethod'")
+				}
+				name, ok := t[1].(MalSymbol)
+				if !ok {
+					return nil, fmt.Errorf("the first parameter is expected to be a symbol")
+				}
+				value, err := env.Get(name)
+				if err != nil {
+					return nil, err
+				}
+				multi, ok := value.(*MalMultiFn)
+				if !ok {
+					return nil, fmt.Errorf("'%s' is not a multimethod", name.Value)
+				}
+				dispatchValue, err := EVAL(t[2], env)
+				if err != nil {
+					return nil, err
+				}
+				method, err := EVAL(MalList{MalSymbol{Value: "fn*"}, t[3], t[4]}, env)
+				if err != nil {
+					return nil, err
+				}
+				return multi, core.AddMethod(multi, dispatchValue, method)
+			case "break": // pause in the debugger
+				if len(t) != 1 {
+					return nil, fmt.Errorf("incorrect number of arguments for 'break'")
+				}
+				return MalNil, dbg.Break(t, env)
+			case "syntax-quote": // `(a ~b ~@c x#)
+				if len(t) != 2 {
+					return nil, fmt.Errorf("incorrect number of arguments for 'syntax-quote'")
+				}
+				// each foo# is the same generated symbol within a template
+				return syntaxQuote(t[1], env, make(map[string]MalSymbol))
+			case "lazy-seq":
+				if len(t) != 2 {
+					return nil, fmt.Errorf("incorrect number of arguments for 'lazy-seq'")
+				}
+				// the body is evaluated only when the sequence gets realized
+				return NewLazySeq(func() (MalType, error) {
+					return EVAL(t[1], env)
+				}), nil
 			case "fn*":
 				if len(t) != 3 {
 					return nil, fmt.Errorf("incorrect number of arguments for 'fn*'")
@@ -180,6 +406,12 @@ func EVAL(ast MalType, env MalEnv) (MalType, error) {
 					if err != nil {
 						return nil, err
 					}
+				case *MalMultiFn: // calling the method selected by the dispatch function
+					return core.CallMulti(f, evaluatedList.(MalList)[1:]...)
+				case *MalTracedFn: // printing the call to the original function
+					return f.Function(evaluatedList.(MalList)[1:]...)
+				case MalKeyword: // looking up itself in a hash map or a record
+					return core.CallKeyword(f, evaluatedList.(MalList)[1:]...)
 				default:
 					return nil, fmt.Errorf("invalid function calling")
 				}
@@ -190,8 +422,12 @@ func EVAL(ast MalType, env MalEnv) (MalType, error) {
 	}
 }
 
-func PRINT(exp MalType) string {
-	return printer.PrintStr(exp, true)
+// PRINT returns an error, instead of the printed text, if a lazy sequence in `exp` fails to be realized
+func PRINT(exp MalType) (string, error) {
+	if err := printer.Realize(exp); err != nil {
+		return "", err
+	}
+	return printer.PrintStr(exp, true), nil
 }
 
 func rep(in string, env MalEnv) string {
@@ -199,34 +435,141 @@ func rep(in string, env MalEnv) string {
 	if err != nil {
 		return fmt.Sprint(err)
 	}
+	return evalPrint(ast, env)
+}
+
+// evalPrint evaluates and prints a form which is already read
+func evalPrint(ast MalType, env MalEnv) string {
 	exp, err := EVAL(ast, env)
 	if err != nil {
 		return fmt.Sprint(err)
 	}
-	output := PRINT(exp)
+	output, err := PRINT(exp)
+	if err != nil {
+		return fmt.Sprint(err)
+	}
 	return output
 }
 
+// readInput reads a line from user, and more lines as long as the input is incomplete,
+// e.g., inside a list or a string, and returns the form read from them or `readErr` if it's invalid
+// The form is returned rather than the input, so that it isn't read (and tagged literals aren't
+// handled by *data-readers*) once more
+func readInput() (ast MalType, readErr error, err error) {
+	input, err := readline.PromptAndRead("user> ")
+	if err != nil {
+		return nil, nil, err
+	}
+	for ast, readErr = READ(input); reader.IsIncomplete(readErr); ast, readErr = READ(input) {
+		more, err := readline.PromptAndRead("...> ")
+		if err != nil {
+			return nil, nil, err
+		}
+		input += "\n" + more
+	}
+	return ast, readErr, nil
+}
+
+// lookupDataReader looks up the handler of `tag` in *data-readers*, a map from tag names to functions
+func lookupDataReader(env MalEnv, tag string) (reader.TagHandler, bool) {
+	readers, err := env.Get(MalSymbol{Value: "*data-readers*"})
+	if err != nil {
+		return nil, false
+	}
+	hashmap, ok := readers.(MalHashmap)
+	if !ok {
+		return nil, false
+	}
+	f, ok := hashmap.Get(MalString{Value: tag})
+	if !ok {
+		return nil, false
+	}
+	return func(form MalType) (MalType, error) {
+		return core.CallFunction(f, form)
+	}, true
+}
+
 func runInitCommands(env MalEnv) {
 	for _, command := range core.InitCommands {
 		_ = rep(command, env) // output is ignored
 	}
 }
 
+// checkFiles prints the syntax errors in the files like FILE:LINE:COLUMN: MESSAGE, which is the format
+// understood by most editors, and tells whether there is none
+func checkFiles(paths []string) bool {
+	ok := true
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			ok = false
+			continue
+		}
+		for _, d := range reader.Check(string(content)) {
+			fmt.Printf("%s:%d:%d: %s\n", path, d.Position.Line, d.Position.Column, d.Message)
+			ok = false
+		}
+	}
+	return ok
+}
+
 func main() {
 	defer readline.Close()
+	// check mode: mal-go -check FILES... only reports syntax errors without evaluating anything
+	if len(os.Args) > 1 && os.Args[1] == "-check" {
+		ok := checkFiles(os.Args[2:])
+		readline.Close()
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
 	replEnv := environment.GetInitEnv()
 	// it breaks the program's structure to add 'eval' function here
 	// but doing so is the simplest way
 	_ = replEnv.Set(MalSymbol{Value: "eval"}, MalFunction(func(args ...MalType) (MalType, error) {
 		return EVAL(args[0], replEnv)
 	}))
+	// so are the file loading functions, which need EVAL as well
+	ld := loader.New(replEnv, EVAL)
+	_ = replEnv.Set(MalSymbol{Value: "load-file"}, MalFunction(ld.LoadFile))
+	_ = replEnv.Set(MalSymbol{Value: "require"}, MalFunction(ld.Require))
+	// the debugger evaluates and reads input just like the REPL
+	dbg = debugger.New(EVAL, readline.PromptAndRead, os.Stdout)
+	for name, f := range dbg.Functions() {
+		_ = replEnv.Set(MalSymbol{Value: name}, f)
+	}
 	runInitCommands(replEnv)
+	// tagged literals look up their handlers in *data-readers* first
+	reader.DataReaders = func(tag string) (reader.TagHandler, bool) {
+		return lookupDataReader(replEnv, tag)
+	}
+	// script mode: mal-go FILE ARGS... evaluates FILE with ARGS bound to *ARGV*
+	argv := MalList{}
+	if len(os.Args) > 2 {
+		for _, arg := range os.Args[2:] {
+			argv = append(argv, MalString{Value: arg})
+		}
+	}
+	_ = replEnv.Set(MalSymbol{Value: "*ARGV*"}, argv)
+	if len(os.Args) > 1 {
+		if _, err := ld.LoadFile(MalString{Value: os.Args[1]}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			readline.Close()
+			os.Exit(1)
+		}
+		return
+	}
 	for { // infinite REPL loop
-		input, err := readline.PromptAndRead("user> ")
+		ast, readErr, err := readInput()
 		if err != nil { // EOF or something unexpected
 			break
 		}
-		fmt.Println(rep(input, replEnv))
+		if readErr != nil {
+			fmt.Println(readErr)
+			continue
+		}
+		fmt.Println(evalPrint(ast, replEnv))
 	}
 }
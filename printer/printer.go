@@ -1,8 +1,14 @@
 package printer
 
 import (
+	"encoding/hex"
+	"fmt"
 	"github.com/keithnull/mal-go/types"
+	"math"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 func printList(lst types.MalList, start, end string, readable bool) string {
@@ -17,35 +23,217 @@ func printList(lst types.MalList, start, end string, readable bool) string {
 	return result
 }
 
-func printHashmap(hm types.MalHashmap, readable bool) string {
-	result := "{"
+// LazySeqLimit is the maximum number of elements printed for a lazy sequence
+// As a lazy sequence may be infinite, the elements beyond the limit are replaced with "..."
+var LazySeqLimit = 100
+
+func printLazySeq(ls *types.MalLazySeq, readable bool) string {
+	result := "("
+	var seq types.MalType = ls
+	for i := 0; ; i++ {
+		first, rest, ok, err := types.SeqNext(seq)
+		if i != 0 && (err != nil || ok) {
+			result += " "
+		}
+		if err != nil { // only reached if the sequence isn't realized by Realize beforehand
+			return result + "#<error: " + err.Error() + ">)"
+		}
+		if !ok {
+			break
+		}
+		if i == LazySeqLimit {
+			result += "..."
+			break
+		}
+		result += PrintStr(first, readable)
+		seq = rest
+	}
+	result += ")"
+	return result
+}
+
+// Realize realizes the lazy sequences in `ast`, as far as they are printed, and returns the first
+// error in doing so, which should be reported instead of printing `ast`
+func Realize(ast types.MalType) error {
+	switch t := ast.(type) {
+	case types.MalList:
+		return realizeAll(t)
+	case types.MalVector:
+		return realizeAll(t.Slice())
+	case types.MalSet:
+		return realizeAll(t.Elements())
+	case types.MalHashmap:
+		return realizeEntries(t.Entries())
+	case types.MalRecord:
+		return realizeEntries(t.Entries())
+	case *types.MalLazySeq:
+		var seq types.MalType = t
+		for i := 0; i <= LazySeqLimit; i++ {
+			first, rest, ok, err := types.SeqNext(seq)
+			if err != nil {
+				return err
+			}
+			if !ok || i == LazySeqLimit {
+				break
+			}
+			if err := Realize(first); err != nil {
+				return err
+			}
+			seq = rest
+		}
+	}
+	return nil
+}
+
+func realizeAll(values []types.MalType) error {
+	for _, v := range values {
+		if err := Realize(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func realizeEntries(entries []types.MapEntry) error {
+	for _, entry := range entries {
+		if err := Realize(entry.Key); err != nil {
+			return err
+		}
+		if err := Realize(entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printFloat formats a float so that reading it back gives exactly the same value
+// It always has a decimal point or an exponent to be distinguished from integers
+func printFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "##Inf"
+	case math.IsInf(f, -1):
+		return "##-Inf"
+	case math.IsNaN(f):
+		return "##NaN"
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-4 || abs >= 1e21) {
+		format = 'e'
+	}
+	result := strconv.FormatFloat(f, format, -1, 64)
+	if !strings.ContainsAny(result, ".e") {
+		result += ".0"
+	}
+	return result
+}
+
+func printHashmap(entries []types.MapEntry, start string, readable bool) string {
+	result := start
 	isFirstPair := true
-	for k, v := range hm {
+	for _, entry := range entries {
 		if !isFirstPair {
 			result += " "
 		}
 		isFirstPair = false
-		result += PrintStr(k, readable)
+		result += PrintStr(entry.Key, readable)
 		result += " "
-		result += PrintStr(v, readable)
+		result += PrintStr(entry.Value, readable)
 	}
 	result += "}"
 	return result
 }
 
+func printSet(set types.MalSet, readable bool) string {
+	return printList(set.Elements(), "#{", "}", readable)
+}
+
+// escapeString returns `s` as a string literal, escaping only \, ", newlines, tabs and other control
+// characters (as \uXXXX), so that the reader reads it back to `s`
+func escapeString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		default: // invalid UTF-8 is kept as it is
+			_, size := utf8.DecodeRuneInString(s[i:])
+			sb.WriteString(s[i : i+size])
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// printRegex returns `pattern` as a regex literal, where quotes are escaped (which a regex reads as
+// quotes as well) as the reader takes backslashes as they are
+func printRegex(pattern string) string {
+	var sb strings.Builder
+	sb.WriteString(`#"`)
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\': // keep an escaped character as it is, which may be a quote
+			sb.WriteByte('\\')
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteByte(pattern[i])
+			}
+		case '"':
+			sb.WriteString(`\"`)
+		default:
+			sb.WriteByte(pattern[i])
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
 // PrintStr converts a Mal AST to string
 // If readable is set to true, then MalString will get escaped properly
 func PrintStr(ast types.MalType, readable bool) string {
 	switch t := ast.(type) {
 	case types.MalNumber:
 		return strconv.Itoa(t.Value)
+	case types.MalBigInt:
+		return t.Value.String()
+	case types.MalRatio: // 3/4
+		return t.Value.String()
+	case types.MalFloat:
+		return printFloat(t.Value)
 	case types.MalSymbol:
 		return t.Value
 	case types.MalString:
 		if readable {
-			return strconv.Quote(t.Value)
+			return escapeString(t.Value)
 		}
 		return t.Value
+	case types.MalChar: // \a
+		if !readable {
+			return string(t.Value)
+		}
+		if name, ok := types.CharName(t.Value); ok {
+			return "\\" + name
+		}
+		return "\\" + string(t.Value)
+	case types.MalInst: // #inst "2026-10-17T00:00:00Z"
+		return `#inst "` + t.Value.Format(time.RFC3339Nano) + `"`
+	case types.MalUUID: // #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"
+		return `#uuid "` + t.Value + `"`
+	case types.MalRegex: // #"\d+"
+		if !readable {
+			return t.Value.String()
+		}
+		return printRegex(t.Value.String())
+	case types.MalBytes: // #bytes "68656c6c6f"
+		return `#bytes "` + hex.EncodeToString(t.Value) + `"`
 	case types.MalLiteral: // nil, true, false
 		return string(t)
 	case types.MalKeyword:
@@ -53,13 +241,21 @@ func PrintStr(ast types.MalType, readable bool) string {
 	case types.MalList: // (foo bar baz)
 		return printList(t, "(", ")", readable)
 	case types.MalVector: // [foo bar baz]
-		return printList(types.MalList(t), "[", "]", readable)
+		return printList(t.Slice(), "[", "]", readable)
 	case types.MalHashmap: // {foo bar}
-		return printHashmap(t, readable)
-	case types.MalFunction:
+		return printHashmap(t.Entries(), "{", readable)
+	case types.MalRecord: // #Point{:x 1 :y 2}
+		return printHashmap(t.Entries(), "#"+t.Type.Name+"{", readable)
+	case types.MalSet: // #{foo bar}
+		return printSet(t, readable)
+	case *types.MalLazySeq: // printed like a list
+		return printLazySeq(t, readable)
+	case types.MalFunction, *types.MalTracedFn:
 		return "#<function>"
 	case types.MalFunctionTCO:
 		return "#<functionTCO>"
+	case *types.MalMultiFn:
+		return "#<multimethod " + t.Name + ">"
 	default:
 		return "/UNKNOWN VALUE/"
 	}
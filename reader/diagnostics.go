@@ -0,0 +1,125 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Diagnostic is a syntax error found by Check()
+type Diagnostic struct {
+	Position Position
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Position, d.Message)
+}
+
+// before tells whether `pos` is before `other`
+func (pos Position) before(other Position) bool {
+	return pos.Line < other.Line || pos.Line == other.Line && pos.Column < other.Column
+}
+
+// closers maps opening delimiters to their closing ones
+var closers = map[string]string{"(": ")", "[": "]", "{": "}", "#{": "}"}
+
+// Check reads all forms in `input` like ReadAll(), but instead of stopping at the first syntax error,
+// it goes on after each one and returns all of them in order
+// After an error, reading resumes right after the top-level form containing it, whose end is found
+// by matching delimiters, or at an opening delimiter in the first column, which most likely starts
+// the next top-level form if the broken one misses closing delimiters.
+func Check(input string) []Diagnostic {
+	tokens := lex(input)
+	tr := TokenReader{tokens, 0}
+	diagnostics := make([]Diagnostic, 0)
+	for tr.position < len(tokens) {
+		start := tr.position
+		if tokens[start].Kind == TokenDiscard { // the discarded forms are checked like others
+			if start == len(tokens)-1 {
+				diagnostics = append(diagnostics, Diagnostic{tokens[start].Position, "unclosed '#_'"})
+			}
+			tr.position++
+			continue
+		}
+		_, err := readForm(&tr)
+		if err == nil {
+			continue
+		}
+		diagnostic := diagnose(err, tokens[start].Position)
+		if next := nextTopLevel(tokens, start); next < len(tokens) && !diagnostic.Position.before(tokens[next].Position) {
+			// the error is in the following forms, which are swallowed as this one isn't closed
+			partial := TokenReader{tokens[:next], start}
+			if _, err := readForm(&partial); err != nil {
+				diagnostic = diagnose(err, tokens[start].Position)
+			}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+		tr.position = resync(tokens, start)
+	}
+	return diagnostics
+}
+
+// diagnose converts an error from readForm() to a diagnostic, located at `pos` if it has no position
+func diagnose(err error, pos Position) Diagnostic {
+	var incomplete *IncompleteError
+	if errors.As(err, &incomplete) {
+		return Diagnostic{incomplete.Position, fmt.Sprintf("unclosed '%s'", incomplete.Delimiter)}
+	}
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return Diagnostic{syntaxErr.Position, syntaxErr.Error()}
+	}
+	return Diagnostic{pos, err.Error()}
+}
+
+// resync returns the index of the token where reading resumes after an error in the form at `start`
+func resync(tokens []Token, start int) int {
+	expected := make([]string, 0) // the closing delimiters expected, innermost last
+	for i := start; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.Kind != TokenDelimiter {
+			if len(expected) == 0 && !isPrefix(token) {
+				return i + 1
+			}
+			continue
+		}
+		if closer, ok := closers[token.Text]; ok {
+			if isTopLevel(tokens, start, i) {
+				return i
+			}
+			expected = append(expected, closer)
+		} else if len(expected) > 0 && token.Text == expected[len(expected)-1] {
+			expected = expected[:len(expected)-1]
+		} // otherwise a closing delimiter that doesn't match, which is skipped
+		if len(expected) == 0 {
+			return i + 1
+		}
+	}
+	return len(tokens)
+}
+
+// isTopLevel tells whether the token at `i` after the form at `start` is an opening delimiter in the
+// first column, which is assumed to start a top-level form
+func isTopLevel(tokens []Token, start, i int) bool {
+	_, ok := closers[tokens[i].Text]
+	return ok && i > start && tokens[i].Kind == TokenDelimiter && tokens[i].Position.Column == 1
+}
+
+// nextTopLevel returns the index of the top-level form after the one at `start`, or the number of
+// tokens if there is none
+func nextTopLevel(tokens []Token, start int) int {
+	for i := start + 1; i < len(tokens); i++ {
+		if isTopLevel(tokens, start, i) {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// isPrefix tells whether `token` is a part of the form after it, e.g., ` in `(a) or #inst in #inst "..."
+func isPrefix(token Token) bool {
+	if token.Kind == TokenSpecial {
+		return true
+	}
+	return token.Kind == TokenAtom && len(token.Text) > 1 && token.Text[0] == '#' && token.Text[1] != '#'
+}
@@ -0,0 +1,197 @@
+package reader
+
+import (
+	"unicode/utf8"
+)
+
+// TokenKind is the type of a token
+type TokenKind int
+
+const (
+	TokenDelimiter       TokenKind = iota // ( ) [ ] { } and #{
+	TokenSpecial                          // ' ` ~ ~@ ^ and @
+	TokenString                           // a string literal with its quotes
+	TokenUnclosedString                   // a string or regex literal whose closing quote is missing
+	TokenRegex                            // a regex literal like #"\d+"
+	TokenChar                             // a character literal like \a
+	TokenAtom                             // anything else, e.g., numbers, symbols and keywords
+	TokenDiscard                          // #_ which discards the next form
+	TokenUnclosedComment                  // a block comment #| ... |# whose end is missing
+)
+
+// Token is a lexical unit of mal source code
+type Token struct {
+	Kind     TokenKind
+	Text     string
+	Position Position // where the token starts
+	End      int      // the byte offset right after the token in the whole input
+}
+
+// isWhitespace tells whether `c` separates tokens, and commas are whitespace in mal
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == ','
+}
+
+// isAtomEnd tells whether `c` ends an atom
+func isAtomEnd(c byte) bool {
+	switch c {
+	case '[', ']', '{', '}', '(', ')', '\'', '"', '`', ';':
+		return true
+	}
+	return isWhitespace(c)
+}
+
+// lexer splits input into tokens in a single pass, keeping track of positions
+// With partial set, more input may follow, so it stops before anything that may be continued by
+// it, i.e., a token or a comment reaching the end. The caller then appends more to the input,
+// which may drop the part already lexed by moving it to base.
+type lexer struct {
+	input   string
+	offset  int
+	line    int
+	column  int
+	base    int // the offset of input in the whole input
+	partial bool
+}
+
+// advance moves forward by `n` bytes, which never end in the middle of a UTF-8 sequence
+func (lx *lexer) advance(n int) {
+	for _, c := range lx.input[lx.offset : lx.offset+n] {
+		if c == '\n' {
+			lx.line++
+			lx.column = 1
+		} else {
+			lx.column++
+		}
+	}
+	lx.offset += n
+}
+
+// scanString returns the length of the string literal, whose opening quote is at `quote`, from the
+// current offset and whether it's closed
+func (lx *lexer) scanString(quote int) (int, bool) {
+	for i := quote + 1; i < len(lx.input); i++ {
+		switch lx.input[i] {
+		case '\\': // skip the escaped character
+			i++
+		case '"':
+			return i + 1 - lx.offset, true
+		}
+	}
+	return len(lx.input) - lx.offset, false
+}
+
+// scanBlockComment returns the length of the block comment at the current offset and whether it's
+// closed. Block comments can be nested, e.g., #| a #| b |# c |#
+func (lx *lexer) scanBlockComment() (int, bool) {
+	depth := 0
+	for i := lx.offset; i+1 < len(lx.input); i++ {
+		switch lx.input[i : i+2] {
+		case "#|":
+			depth++
+			i++
+		case "|#":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1 - lx.offset, true
+			}
+		}
+	}
+	return len(lx.input) - lx.offset, false
+}
+
+// scanAtom returns the length of the atom starting at `from`
+func (lx *lexer) scanAtom(from int) int {
+	i := from
+	for i < len(lx.input) && !isAtomEnd(lx.input[i]) {
+		i++
+	}
+	return i - lx.offset
+}
+
+// next returns the next token, skipping whitespace and comments, and false at the end of input
+// An unclosed block comment is returned as a token so that the reader can tell it's incomplete.
+func (lx *lexer) next() (Token, bool) {
+	for lx.offset < len(lx.input) {
+		c := lx.input[lx.offset]
+		if isWhitespace(c) {
+			lx.advance(1)
+			continue
+		}
+		if c == ';' { // a comment till the end of line
+			n := 0
+			for lx.offset+n < len(lx.input) && lx.input[lx.offset+n] != '\n' {
+				n++
+			}
+			if lx.partial && lx.offset+n == len(lx.input) {
+				return Token{}, false
+			}
+			lx.advance(n)
+			continue
+		}
+		if c == '#' && lx.offset+1 < len(lx.input) && lx.input[lx.offset+1] == '|' {
+			n, closed := lx.scanBlockComment()
+			if !closed {
+				if lx.partial {
+					return Token{}, false
+				}
+				token := Token{TokenUnclosedComment, lx.input[lx.offset:], Position{lx.line, lx.column}, lx.base + len(lx.input)}
+				lx.advance(n)
+				return token, true
+			}
+			lx.advance(n)
+			continue
+		}
+		kind, n := TokenAtom, 0
+		switch {
+		case c == '#' && lx.offset+1 < len(lx.input) && lx.input[lx.offset+1] == '_':
+			kind, n = TokenDiscard, 2
+		case c == '~' && lx.offset+1 < len(lx.input) && lx.input[lx.offset+1] == '@':
+			kind, n = TokenSpecial, 2
+		case c == '#' && lx.offset+1 < len(lx.input) && lx.input[lx.offset+1] == '{':
+			kind, n = TokenDelimiter, 2
+		case c == '(' || c == ')' || c == '[' || c == ']' || c == '{' || c == '}':
+			kind, n = TokenDelimiter, 1
+		case c == '\'' || c == '`' || c == '~' || c == '^' || c == '@':
+			kind, n = TokenSpecial, 1
+		case c == '"':
+			var closed bool
+			if n, closed = lx.scanString(lx.offset); closed {
+				kind = TokenString
+			} else {
+				kind = TokenUnclosedString
+			}
+		case c == '#' && lx.offset+1 < len(lx.input) && lx.input[lx.offset+1] == '"':
+			var closed bool
+			if n, closed = lx.scanString(lx.offset + 1); closed {
+				kind = TokenRegex
+			} else {
+				kind = TokenUnclosedString
+			}
+		case c == '\\' && lx.offset+1 < len(lx.input) && lx.input[lx.offset+1] != '\n':
+			// the character right after the backslash is always part of it, even ( or a space
+			_, size := utf8.DecodeRuneInString(lx.input[lx.offset+1:])
+			kind, n = TokenChar, lx.scanAtom(lx.offset+1+size)
+		default:
+			n = lx.scanAtom(lx.offset)
+		}
+		if lx.partial && lx.offset+n == len(lx.input) {
+			return Token{}, false
+		}
+		token := Token{kind, lx.input[lx.offset : lx.offset+n], Position{lx.line, lx.column}, lx.base + lx.offset + n}
+		lx.advance(n)
+		return token, true
+	}
+	return Token{}, false
+}
+
+// lex splits `input` into tokens, without whitespace and comments
+func lex(input string) []Token {
+	lx := lexer{input: input, line: 1, column: 1}
+	tokens := make([]Token, 0)
+	for token, ok := lx.next(); ok; token, ok = lx.next() {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
@@ -0,0 +1,186 @@
+package reader
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// looksNumeric tells whether `s` starts like a number, i.e., with a digit after an optional sign
+// Such a token must be a valid number, rather than a symbol.
+func looksNumeric(s string) bool {
+	i := skipSign(s, 0)
+	return i < len(s) && isDigit(s[i], 10)
+}
+
+// readNumber reads a number in any of the forms below, with optional signs and underscores between digits:
+//   - decimal integers like 42, 1_000_000 or 42N
+//   - integers with a prefix like 0xFF, 0o17 or 0b1010
+//   - integers with a radix from 2 to 36 like 36rZZ or 2r1010
+//   - ratios like 3/4
+//   - floats like 1.5, 1e-3 or 6.022_140e23
+func readNumber(token string) (types.MalType, error) {
+	s := token[skipSign(token, 0):]
+	negative := token[0] == '-'
+	if len(s) >= 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X':
+			return readInteger(token, strings.TrimSuffix(s[2:], "N"), 16, negative)
+		case 'o', 'O':
+			return readInteger(token, strings.TrimSuffix(s[2:], "N"), 8, negative)
+		case 'b', 'B':
+			return readInteger(token, strings.TrimSuffix(s[2:], "N"), 2, negative)
+		}
+	}
+	if i, _ := skipDigits(s, 0); i < len(s) && (s[i] == 'r' || s[i] == 'R') {
+		radix, err := strconv.Atoi(s[:i])
+		if err != nil || radix < 2 || radix > 36 {
+			return nil, fmt.Errorf("invalid number: %s: radix must be between 2 and 36", token)
+		}
+		return readInteger(token, s[i+1:], radix, negative)
+	}
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return readRatio(token, s[:i], s[i+1:], negative)
+	}
+	if strings.ContainsAny(s, ".eE") {
+		return readFloat(token)
+	}
+	return readInteger(token, strings.TrimSuffix(s, "N"), 10, negative)
+}
+
+// readInteger reads `digits` in `base`, which are a part of `token`
+func readInteger(token, digits string, base int, negative bool) (types.MalType, error) {
+	digits, err := removeUnderscores(token, digits, base)
+	if err != nil {
+		return nil, err
+	}
+	if digits == "" {
+		return nil, fmt.Errorf("invalid number: %s: digits are expected", token)
+	}
+	for i := 0; i < len(digits); i++ {
+		if !isDigit(digits[i], base) {
+			return nil, fmt.Errorf("invalid number: %s: invalid digit '%c' in base %d", token, digits[i], base)
+		}
+	}
+	number, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %s", token)
+	}
+	if negative {
+		number.Neg(number)
+	}
+	return types.NormalizeBigInt(number), nil
+}
+
+// readRatio reads a ratio whose numerator and denominator are decimal integers
+func readRatio(token, numerator, denominator string, negative bool) (types.MalType, error) {
+	num, err := readInteger(token, numerator, 10, negative)
+	if err != nil {
+		return nil, err
+	}
+	denom, err := readInteger(token, denominator, 10, false)
+	if err != nil {
+		return nil, err
+	}
+	d := toBigInt(denom)
+	if d.Sign() == 0 {
+		return nil, fmt.Errorf("invalid ratio: %s", token)
+	}
+	return types.NormalizeRat(new(big.Rat).SetFrac(toBigInt(num), d)), nil
+}
+
+// toBigInt converts an integer returned by readInteger() to *big.Int
+func toBigInt(n types.MalType) *big.Int {
+	if b, ok := n.(types.MalBigInt); ok {
+		return b.Value
+	}
+	return big.NewInt(int64(n.(types.MalNumber).Value))
+}
+
+// readFloat reads a float like 1.5, -1.5e10 or 1e-3
+func readFloat(token string) (types.MalType, error) {
+	s, err := removeUnderscores(token, token, 10)
+	if err != nil {
+		return nil, err
+	}
+	if !isFloat(s) {
+		return nil, fmt.Errorf("invalid number: %s", token)
+	}
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %s: out of range", token)
+	}
+	return types.MalFloat{Value: number}, nil
+}
+
+// removeUnderscores removes the underscores in `s`, each of which must be between two digits in `base`
+func removeUnderscores(token, s string, base int) (string, error) {
+	if !strings.Contains(s, "_") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i == 0 || i == len(s)-1 || !isDigit(s[i-1], base) || !isDigit(s[i+1], base) {
+			return "", fmt.Errorf("invalid number: %s: underscores must be between digits", token)
+		}
+	}
+	return sb.String(), nil
+}
+
+// isDigit tells whether `c` is a digit in `base`, where letters are digits from 10 to 35 in either case
+func isDigit(c byte, base int) bool {
+	var value int
+	switch {
+	case c >= '0' && c <= '9':
+		value = int(c - '0')
+	case c >= 'a' && c <= 'z':
+		value = int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		value = int(c-'A') + 10
+	default:
+		return false
+	}
+	return value < base
+}
+
+// skipSign returns the index after an optional sign at `i`
+func skipSign(s string, i int) int {
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		return i + 1
+	}
+	return i
+}
+
+// skipDigits returns the index after the decimal digits from `i`, and whether there is any
+func skipDigits(s string, i int) (int, bool) {
+	start := i
+	for i < len(s) && isDigit(s[i], 10) {
+		i++
+	}
+	return i, i > start
+}
+
+// isFloat tells whether `s` is like 1.5, -1.5e10 or 1e-3
+func isFloat(s string) bool {
+	i, ok := skipDigits(s, skipSign(s, 0))
+	if !ok {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		if i, ok = skipDigits(s, i+1); !ok {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		if i, ok = skipDigits(s, skipSign(s, i+1)); !ok {
+			return false
+		}
+	}
+	return i == len(s)
+}
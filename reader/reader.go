@@ -1,28 +1,83 @@
 package reader
 
 import (
+	"errors"
 	"fmt"
 	"github.com/keithnull/mal-go/types"
-	"regexp"
+	"math"
 	"strconv"
+	"unicode/utf8"
 )
 
-var (
-	tokenRegexp = `[\s,]*(~@|[\[\]{}()'` + "`" +
-		`~^@]|"(?:\\.|[^\\"])*"?|;.*|[^\s\[\]{}('"` + "`" + `,;)]*)`
-)
+// errNoMoreTokens means the input ends in the middle of a form
+var errNoMoreTokens = errors.New("running out of tokens")
+
+// Position is a location in the input, where both Line and Column start from 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("line %d, column %d", pos.Line, pos.Column)
+}
+
+// IncompleteError means the input ends before a form is complete, e.g., inside a list or a string
+// It's not a syntax error, so a REPL can read more lines and try again.
+type IncompleteError struct {
+	Delimiter string   // the opening delimiter which isn't closed, e.g., "(" or `"`
+	Position  Position // where the delimiter is
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("incomplete input: unclosed '%s' at %s", e.Delimiter, e.Position)
+}
+
+// IsIncomplete tells whether `err` is caused by incomplete input
+func IsIncomplete(err error) bool {
+	var incomplete *IncompleteError
+	return errors.As(err, &incomplete)
+}
+
+// SyntaxError is an error in the form at Position
+// Its message is the same as the underlying error, and Position is for tools like Check().
+type SyntaxError struct {
+	Err      error
+	Position Position
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// atPosition attaches `pos` to `err` unless it already has a position, so that an error is located
+// at the innermost form causing it. errNoMoreTokens is kept as it is, which is compared directly.
+func atPosition(err error, pos Position) error {
+	if err == errNoMoreTokens {
+		return err
+	}
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) || IsIncomplete(err) {
+		return err
+	}
+	return &SyntaxError{err, pos}
+}
 
 // Reader is a abstract interface with two methods:
-// - Next() (string, error): returns the token at the current position and increments the position
-// - Peek() (string, error): returns the token at the current position
+// - Next() (Token, error): returns the token at the current position and increments the position
+// - Peek() (Token, error): returns the token at the current position
 type Reader interface {
-	Next() (string, error)
-	Peek() (string, error)
+	Next() (Token, error)
+	Peek() (Token, error)
 }
 
 // TokenReader implements Reader interface
 type TokenReader struct {
-	tokens   []string
+	tokens   []Token
 	position int
 }
 
@@ -32,23 +87,23 @@ func (tr *TokenReader) anyError() error {
 		return fmt.Errorf("nil TokenReader")
 	}
 	if tr.position >= len(tr.tokens) {
-		return fmt.Errorf("running out of tokens")
+		return errNoMoreTokens
 	}
 	return nil
 }
 
 // Peek returns the token at the current position
-func (tr *TokenReader) Peek() (string, error) {
+func (tr *TokenReader) Peek() (Token, error) {
 	if err := tr.anyError(); err != nil {
-		return "", err
+		return Token{}, err
 	}
 	return tr.tokens[tr.position], nil
 }
 
 // Next returns the token at the current position and increments the position
-func (tr *TokenReader) Next() (string, error) {
+func (tr *TokenReader) Next() (Token, error) {
 	if err := tr.anyError(); err != nil {
-		return "", err
+		return Token{}, err
 	}
 	token := tr.tokens[tr.position]
 	tr.position += 1
@@ -57,81 +112,146 @@ func (tr *TokenReader) Next() (string, error) {
 
 // ReadStr builds a Mal AST with the given string
 func ReadStr(input string) (types.MalType, error) {
-	// call tokenize()
-	tokens, err := tokenize(input)
-	if err != nil {
-		return nil, err
-	}
+	// call lex()
+	tokens := lex(input)
 	if len(tokens) == 0 {
 		return nil, fmt.Errorf("empty input")
 	}
 	// create a new Reader instance
 	tr := TokenReader{tokens, 0}
+	if err := skipDiscarded(&tr); err != nil {
+		return nil, err
+	}
+	if tr.position == len(tokens) { // all discarded
+		return nil, fmt.Errorf("empty input")
+	}
 	// call readForm() with the Reader instance
-	return readForm(&tr)
-}
-
-// take a single string and return a slice of all the tokens (strings) in it
-func tokenize(input string) ([]string, error) {
-	re, err := regexp.Compile(tokenRegexp)
+	form, err := readForm(&tr)
 	if err != nil {
 		return nil, err
 	}
-	tokens := make([]string, 0)
-	for _, group := range re.FindAllStringSubmatch(input, -1) {
-		token := group[1]
-		if token == "" || token[0] == ';' { // ignore empty tokens and comments
-			continue
+	if err := skipDiscarded(&tr); err != nil {
+		return nil, err
+	}
+	if tr.position < len(tokens) { // only one form is expected
+		if trailing := tokens[tr.position]; trailing.Kind == TokenUnclosedComment {
+			return nil, &IncompleteError{"#|", trailing.Position}
 		}
-		tokens = append(tokens, token)
+		return nil, fmt.Errorf("unexpected trailing input: %s", tokens[tr.position].Text)
 	}
-	return tokens, nil
+	return form, nil
+}
+
+// skipDiscarded skips the forms discarded by #_, e.g., both 1 and 2 in "#_ #_ 1 2"
+func skipDiscarded(rd Reader) error {
+	for token, err := rd.Peek(); err == nil && token.Kind == TokenDiscard; token, err = rd.Peek() {
+		_, _ = rd.Next()
+		if _, err := readForm(rd); err == errNoMoreTokens {
+			return &IncompleteError{token.Text, token.Position}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func readForm(rd Reader) (types.MalType, error) {
+	if err := skipDiscarded(rd); err != nil {
+		return nil, err
+	}
 	token, err := rd.Peek()
 	if err != nil {
 		return nil, err
 	}
-	switch token {
+	form, err := readFormAt(rd, token)
+	if err != nil {
+		return nil, atPosition(err, token.Position)
+	}
+	return form, nil
+}
+
+// readFormAt reads the form starting with `token`, which is peeked but not consumed yet
+func readFormAt(rd Reader, token Token) (types.MalType, error) {
+	if token.Kind == TokenUnclosedComment {
+		return nil, &IncompleteError{"#|", token.Position}
+	}
+	switch token.Text {
 	case "(":
 		return readList(rd)
 	case ")":
-		return nil, fmt.Errorf("unexpected ']'")
+		return nil, fmt.Errorf("unexpected ')'")
 	case "[":
 		return readVector(rd)
 	case "]":
-		return nil, fmt.Errorf("unexpected ']")
+		return nil, fmt.Errorf("unexpected ']'")
 	case "{":
 		return readHashmap(rd)
+	case "#{":
+		return readSet(rd)
 	case "}":
-		return nil, fmt.Errorf("unexpected '}")
+		return nil, fmt.Errorf("unexpected '}'")
+	case "`", "~", "~@":
+		return readMacro(rd, readerMacros[token.Text])
 	default:
+		if token.Kind == TokenAtom && len(token.Text) > 1 && token.Text[0] == '#' && token.Text[1] != '#' { // not ##Inf
+			return readTagged(rd)
+		}
 		return readAtom(rd)
 	}
 }
 
+// readerMacros maps the reader macros to the symbols of the forms they stand for
+var readerMacros = map[string]string{
+	"`":  "syntax-quote",
+	"~":  "unquote",
+	"~@": "splice-unquote",
+}
+
+// readMacro reads a reader macro followed by a form, e.g., `(a ~b) as (syntax-quote (a (unquote b)))
+func readMacro(rd Reader, symbol string) (types.MalType, error) {
+	token, _ := rd.Next()
+	form, err := readForm(rd)
+	if err == errNoMoreTokens {
+		return nil, &IncompleteError{token.Text, token.Position}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return types.MalList{types.MalSymbol{Value: symbol}, form}, nil
+}
+
 func readAtom(rd Reader) (types.MalType, error) {
-	token, err := rd.Next()
+	next, err := rd.Next()
 	if err != nil {
 		return nil, err
 	}
-	// there should be no error in MatchString()
-	if matched, _ := regexp.MatchString(`^[-+]?\d+$`, token); matched { // number
-		number, err := strconv.Atoi(token)
-		if err != nil {
-			return nil, err
-		}
-		return types.MalNumber{Value: number}, nil
-	} else if matched, _ := regexp.MatchString(`^"(?:\\.|[^\\"])*"?$`, token); matched { // string
-		if matched, _ := regexp.MatchString(`^"(?:\\.|[^\\"])*"$`, token); !matched {
-			return nil, fmt.Errorf("unclosed string: %s", token)
-		}
-		unquoted, err := strconv.Unquote(token) // unquote and handle escape chars gracefully
+	switch next.Kind {
+	case TokenString:
+		unquoted, err := unescapeString(next.Text)
 		if err != nil {
 			return nil, err
 		}
 		return types.MalString{Value: unquoted}, nil
+	case TokenUnclosedString:
+		delimiter := `"`
+		if next.Text[0] == '#' {
+			delimiter = `#"`
+		}
+		return nil, &IncompleteError{delimiter, next.Position}
+	case TokenRegex: // compiled only once here, and backslashes are not escapes
+		return types.NewRegex(next.Text[2 : len(next.Text)-1])
+	case TokenChar:
+		return readChar(next.Text)
+	}
+	token := next.Text
+	if looksNumeric(token) {
+		return readNumber(token)
+	} else if token == "##Inf" {
+		return types.MalFloat{Value: math.Inf(1)}, nil
+	} else if token == "##-Inf" {
+		return types.MalFloat{Value: math.Inf(-1)}, nil
+	} else if token == "##NaN" {
+		return types.MalFloat{Value: math.NaN()}, nil
 	} else if token == "nil" {
 		return types.MalNil, nil
 	} else if token == "true" {
@@ -145,20 +265,49 @@ func readAtom(rd Reader) (types.MalType, error) {
 	}
 }
 
+// readChar reads a character literal like \a, \é, \newline or \u00e9
+func readChar(token string) (types.MalType, error) {
+	name := token[1:]
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return types.MalChar{Value: r}, nil
+	}
+	if r, ok := types.CharByName(name); ok {
+		return types.MalChar{Value: r}, nil
+	}
+	if len(name) == 5 && name[0] == 'u' {
+		// surrogates aren't valid code points on their own
+		if code, err := strconv.ParseUint(name[1:], 16, 32); err == nil && utf8.ValidRune(rune(code)) {
+			return types.MalChar{Value: rune(code)}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid character: %s", token)
+}
+
 // readStartEnd assumes the next token is `start` and reads till `end`
 // It returns a list of Mal objects
 // If any error encountered, it will stop reading immediately and return that error
 func readStartEnd(rd Reader, start, end string) (types.MalList, error) {
 	// sanity check as last peek we already saw the starting token
 	first, _ := rd.Next()
-	if first != start {
-		return nil, fmt.Errorf("incorrect starting token: expect '%s' but get '%s'", start, first)
+	if first.Text != start {
+		return nil, fmt.Errorf("incorrect starting token: expect '%s' but get '%s'", start, first.Text)
 	}
 	astList := types.MalList{}
-	for token, err := rd.Peek(); token != end; token, err = rd.Peek() {
+	for {
+		if err := skipDiscarded(rd); err != nil {
+			return nil, err
+		}
+		token, err := rd.Peek()
+		if err == errNoMoreTokens { // the input ends before `end`
+			return nil, &IncompleteError{start, first.Position}
+		}
 		if err != nil {
 			return nil, err
 		}
+		if token.Kind == TokenDelimiter && token.Text == end {
+			break
+		}
 		ast, err := readForm(rd)
 		if err != nil {
 			return nil, err
@@ -179,7 +328,7 @@ func readVector(rd Reader) (types.MalType, error) {
 	if err != nil {
 		return nil, err
 	}
-	return types.MalVector(list), nil
+	return types.NewVector(list...), nil
 }
 
 func readHashmap(rd Reader) (types.MalType, error) {
@@ -190,14 +339,27 @@ func readHashmap(rd Reader) (types.MalType, error) {
 	if len(list)%2 != 0 {
 		return nil, fmt.Errorf("incorrect number of elements for a hashmap")
 	}
-	hashmap := make(types.MalHashmap)
-	for i := 0; i < len(list); i += 2 {
-		switch t := list[i].(type) {
-		case types.MalKeyword, types.MalString:
-			hashmap[t] = list[i+1]
-		default:
-			return nil, fmt.Errorf("hashmap keys only accept string or keyword")
-		}
+	hashmap, err := types.NewHashmap(list...)
+	if err != nil {
+		return nil, err
+	}
+	if hashmap.Count() != len(list)/2 {
+		return nil, fmt.Errorf("duplicate key in a hashmap literal")
 	}
 	return hashmap, nil
 }
+
+func readSet(rd Reader) (types.MalType, error) {
+	list, err := readStartEnd(rd, "#{", "}")
+	if err != nil {
+		return nil, err
+	}
+	set, err := types.NewSet(list...)
+	if err != nil {
+		return nil, err
+	}
+	if set.Count() != len(list) {
+		return nil, fmt.Errorf("duplicate element in a set literal")
+	}
+	return set, nil
+}
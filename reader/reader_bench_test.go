@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// regexpTokenRegexp is the regular expression the reader used to tokenize with, kept to compare with
+var regexpTokenRegexp = `[\s,]*(~@|#\{|[\[\]{}()'` + "`" +
+	`~^@]|"(?:\\.|[^\\"])*"?|;.*|\\.[^\s\[\]{}('"` + "`" + `,;)]*|[^\s\[\]{}('"` + "`" + `,;)]*)`
+
+// regexpTokenize is how the reader used to tokenize, compiling the regular expression every time
+func regexpTokenize(input string) []string {
+	re := regexp.MustCompile(regexpTokenRegexp)
+	tokens := make([]string, 0)
+	for _, group := range re.FindAllStringSubmatch(input, -1) {
+		if token := group[1]; token != "" && token[0] != ';' {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// benchmarkSource is a mal program of typical forms, repeated to the size of a larger file
+var benchmarkSource = strings.Repeat(`
+;; compute the sum of squares
+(def! sum-squares (fn* (xs acc)
+  (if (empty? xs)
+    acc
+    (sum-squares (rest xs) (+ acc (* (first xs) (first xs)))))))
+(def! config {:name "a \"quoted\" name" :ratio 3/4 :rate 1.5e-3 :big 123456789012345678901234567890})
+(def! chars [\a \newline \space é])
+(def! tags #{:x :y :z})
+`, 200)
+
+func BenchmarkRegexpTokenize(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkSource)))
+	for i := 0; i < b.N; i++ {
+		regexpTokenize(benchmarkSource)
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkSource)))
+	for i := 0; i < b.N; i++ {
+		lex(benchmarkSource)
+	}
+}
+
+func BenchmarkReadAll(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkSource)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadAll(strings.NewReader(benchmarkSource)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadStr(b *testing.B) {
+	form := "(do " + benchmarkSource + ")"
+	b.SetBytes(int64(len(form)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadStr(form); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
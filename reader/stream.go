@@ -0,0 +1,108 @@
+package reader
+
+import (
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"io"
+)
+
+// chunkSize is the number of bytes FormReader reads at a time
+const chunkSize = 4096
+
+// FormReader reads top-level forms one at a time from an io.Reader
+// Input is read in chunks only as much as needed for the next form, and lexed incrementally, so
+// that a huge file (even a single line) is never held in memory as a whole and each part of it is
+// lexed only once. The exception is a token or a comment spanning chunks, e.g., a long multi-line
+// string, which is lexed again from its start after each chunk till it ends.
+type FormReader struct {
+	in     io.Reader
+	lx     lexer   // its input is the part not lexed yet, followed by the chunks read after
+	tokens []Token // tokens not consumed by forms yet
+	depth  int     // the nesting depth of delimiters at the end of tokens
+	ready  bool    // whether a form may be complete, i.e., depth got back to 0 since the last try
+	eof    bool
+}
+
+// NewFormReader creates a FormReader reading from `r`
+func NewFormReader(r io.Reader) *FormReader {
+	return &FormReader{in: r, lx: lexer{line: 1, column: 1, partial: true}}
+}
+
+// readChunk reads the next chunk of input and lexes it
+func (fr *FormReader) readChunk() error {
+	chunk := make([]byte, chunkSize)
+	n, err := fr.in.Read(chunk)
+	if err == io.EOF {
+		fr.eof, fr.lx.partial = true, false
+	} else if err != nil {
+		return err
+	}
+	// drop the part already lexed, whose tokens don't refer to the new input
+	fr.lx.base += fr.lx.offset
+	fr.lx.input, fr.lx.offset = fr.lx.input[fr.lx.offset:]+string(chunk[:n]), 0
+	for token, ok := fr.lx.next(); ok; token, ok = fr.lx.next() {
+		fr.tokens = append(fr.tokens, token)
+		if token.Kind == TokenDelimiter {
+			if _, ok := closers[token.Text]; ok {
+				fr.depth++
+			} else {
+				fr.depth--
+			}
+		}
+		if fr.depth <= 0 {
+			fr.ready = true
+		}
+	}
+	return nil
+}
+
+// Next returns the next form, or io.EOF if there is none left
+// If the input ends in the middle of a form, an IncompleteError is returned.
+// If a form is invalid, the error is returned and the tokens read so far are dropped.
+func (fr *FormReader) Next() (types.MalType, error) {
+	for {
+		if fr.ready || fr.eof {
+			tr := TokenReader{fr.tokens, 0}
+			err := skipDiscarded(&tr)
+			if err == nil && tr.position == len(fr.tokens) { // no forms but discarded ones
+				fr.tokens = fr.tokens[tr.position:]
+				if fr.eof {
+					return nil, io.EOF
+				}
+			} else {
+				var form types.MalType
+				if err == nil {
+					form, err = readForm(&tr)
+				}
+				if err == nil {
+					fr.tokens = fr.tokens[tr.position:] // a complete form keeps the depth
+					return form, nil
+				}
+				if !IsIncomplete(err) || fr.eof {
+					fr.tokens, fr.depth = nil, 0
+					return nil, err
+				}
+			}
+			fr.ready = false
+		}
+		if err := fr.readChunk(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadAll reads all forms from `r`
+func ReadAll(r io.Reader) ([]types.MalType, error) {
+	fr := NewFormReader(r)
+	forms := make([]types.MalType, 0)
+	for {
+		form, err := fr.Next()
+		if err == io.EOF {
+			return forms, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("form %d: %w", len(forms)+1, err)
+		}
+		forms = append(forms, form)
+	}
+}
@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// unescapeString returns the content of the string literal `token` with its escapes replaced
+// The escapes are \n, \t, \", \\ and \uXXXX, where a surrogate pair like \ud83d\ude00 makes up one
+// character. Any other character, including a newline, stands for itself.
+func unescapeString(token string) (string, error) {
+	s := token[1 : len(token)-1]
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++ // the lexer makes sure that a backslash is always followed by a character
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case '"', '\\':
+			sb.WriteByte(s[i])
+		case 'u':
+			r, err := readCodeUnit(s, i+1)
+			if err != nil {
+				return "", err
+			}
+			i += 4
+			if utf16.IsSurrogate(r) { // the low surrogate must follow
+				var low rune = -1
+				if strings.HasPrefix(s[i+1:], `\u`) {
+					low, _ = readCodeUnit(s, i+3)
+				}
+				if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+					return "", fmt.Errorf("invalid escape in string: unpaired surrogate \\u%s", s[i-3:i+1])
+				}
+				i += 6
+			}
+			sb.WriteRune(r)
+		default:
+			c, _ := utf8.DecodeRuneInString(s[i:])
+			return "", fmt.Errorf("invalid escape in string: \\%c", c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// readCodeUnit reads the 4 hex digits of \uXXXX at `i`
+func readCodeUnit(s string, i int) (rune, error) {
+	if i+4 > len(s) {
+		return 0, fmt.Errorf("invalid escape in string: \\u%s", s[i:])
+	}
+	code, err := strconv.ParseUint(s[i:i+4], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid escape in string: \\u%s", s[i:i+4])
+	}
+	return rune(code), nil
+}
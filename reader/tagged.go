@@ -0,0 +1,111 @@
+package reader
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/keithnull/mal-go/types"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TagHandler converts the form following a tag to a value, e.g., the string after #inst
+type TagHandler func(form types.MalType) (types.MalType, error)
+
+// tagHandlers are the handlers of built-in tags and the ones registered with RegisterTag()
+var tagHandlers = map[string]TagHandler{
+	"inst":  readInst,
+	"uuid":  readUUID,
+	"bytes": readBytes,
+}
+
+// RegisterTag registers `handler` for `tag`, replacing the existing one
+func RegisterTag(tag string, handler TagHandler) {
+	tagHandlers[tag] = handler
+}
+
+// DataReaders looks up the handlers defined at runtime, which take precedence over registered ones
+// It's set by the interpreter to look up *data-readers* in mal.
+var DataReaders func(tag string) (TagHandler, bool)
+
+// readTagged reads a tagged literal like #inst "2026-10-17T00:00:00Z", where the form after the tag
+// is converted by its handler, or a record like #Point{:x 1 :y 2}
+func readTagged(rd Reader) (types.MalType, error) {
+	token, err := rd.Next()
+	if err != nil {
+		return nil, err
+	}
+	tag := token.Text[1:]
+	form, err := readForm(rd)
+	if err == errNoMoreTokens {
+		return nil, &IncompleteError{token.Text, token.Position}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if DataReaders != nil {
+		if handler, ok := DataReaders(tag); ok {
+			return handler(form)
+		}
+	}
+	if handler, ok := tagHandlers[tag]; ok {
+		return handler(form)
+	}
+	if rt, ok := types.LookupRecordType(tag); ok {
+		hashmap, ok := form.(types.MalHashmap)
+		if !ok {
+			return nil, fmt.Errorf("a hashmap is expected after '%s'", token.Text)
+		}
+		return types.NewRecord(rt, hashmap), nil
+	}
+	return nil, fmt.Errorf("no reader function for tag: %s", tag)
+}
+
+// assertTagString asserts that the form after `tag` is a string
+func assertTagString(tag string, form types.MalType) (string, error) {
+	s, ok := form.(types.MalString)
+	if !ok {
+		return "", fmt.Errorf("a string is expected after '#%s'", tag)
+	}
+	return s.Value, nil
+}
+
+// readInst reads an RFC 3339 timestamp, where the time and the time zone can be omitted
+func readInst(form types.MalType) (types.MalType, error) {
+	s, err := assertTagString("inst", form)
+	if err != nil {
+		return nil, err
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return types.MalInst{Value: t}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid instant: %s", s)
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func readUUID(form types.MalType) (types.MalType, error) {
+	s, err := assertTagString("uuid", form)
+	if err != nil {
+		return nil, err
+	}
+	if !uuidRegexp.MatchString(s) {
+		return nil, fmt.Errorf("invalid uuid: %s", s)
+	}
+	return types.MalUUID{Value: strings.ToLower(s)}, nil
+}
+
+// readBytes reads bytes in hex, as they are printed
+func readBytes(form types.MalType) (types.MalType, error) {
+	s, err := assertTagString("bytes", form)
+	if err != nil {
+		return nil, err
+	}
+	content, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex string: %v", err)
+	}
+	return types.MalBytes{Value: content}, nil
+}
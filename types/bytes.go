@@ -0,0 +1,7 @@
+package types
+
+// MalBytes is an immutable array of bytes for binary data
+// Functions must never modify Value in place since it may be shared.
+type MalBytes struct {
+	Value []byte
+}
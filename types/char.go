@@ -0,0 +1,32 @@
+package types
+
+// MalChar is a single Unicode character, written as \a, \é or \newline
+type MalChar struct {
+	Value rune
+}
+
+// charNames are the names of characters which can't be written as themselves after a backslash
+var charNames = map[rune]string{
+	'\n': "newline",
+	' ':  "space",
+	'\t': "tab",
+	'\r': "return",
+	'\b': "backspace",
+	'\f': "formfeed",
+}
+
+// CharName returns the name of `r` if it has one, e.g., "newline" for '\n'
+func CharName(r rune) (string, bool) {
+	name, ok := charNames[r]
+	return name, ok
+}
+
+// CharByName returns the character named `name`, e.g., '\n' for "newline"
+func CharByName(name string) (rune, bool) {
+	for r, n := range charNames {
+		if n == name {
+			return r, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,257 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// Hash computes the hash of `v` from its structure, so that equal values (see Equal()) always
+// have the same hash. An error is returned if `v` can't be hashed, e.g., a function.
+func Hash(v MalType) (uint32, error) {
+	switch t := v.(type) {
+	case MalNumber:
+		return mixHash(uint64(t.Value)), nil
+	case MalFloat:
+		if t.Value == 0 { // +0.0 and -0.0 are equal
+			return mixHash(0) ^ 0x2f, nil
+		}
+		return mixHash(math.Float64bits(t.Value)) ^ 0x2f, nil
+	case MalBigInt:
+		return stringHash("bigint", t.Value.String()), nil
+	case MalRatio:
+		return stringHash("ratio", t.Value.String()), nil
+	case MalString:
+		return stringHash("string", t.Value), nil
+	case MalKeyword:
+		return stringHash("keyword", t.Value), nil
+	case MalBytes:
+		return stringHash("bytes", string(t.Value)), nil
+	case MalInst:
+		return mixHash(uint64(t.Value.UnixNano())) ^ 0x1a5, nil
+	case MalUUID:
+		return stringHash("uuid", t.Value), nil
+	case MalRegex:
+		return stringHash("regex", t.Value.String()), nil
+	case MalChar:
+		return mixHash(uint64(t.Value)) ^ 0xc4, nil
+	case MalSymbol:
+		return stringHash("symbol", t.Value), nil
+	case MalLiteral:
+		return stringHash("literal", string(t)), nil
+	case MalList:
+		return orderedHash(0x1157, t)
+	case *MalLazySeq: // equal to the list of its elements
+		return seqHash(t)
+	case MalVector:
+		return orderedHash(0x7ec7, t.Slice())
+	case MalHashmap:
+		var h uint32 = 0x4a5
+		for _, entry := range t.Entries() {
+			hk, err := Hash(entry.Key)
+			if err != nil {
+				return 0, err
+			}
+			hv, err := Hash(entry.Value)
+			if err != nil {
+				return 0, err
+			}
+			h += hk ^ (hv * 31) // order independent
+		}
+		return h, nil
+	case MalRecord:
+		h, err := Hash(t.fields)
+		if err != nil {
+			return 0, err
+		}
+		return h ^ stringHash("record", t.Type.Name), nil
+	case MalSet:
+		var h uint32 = 0x5e7
+		for _, element := range t.Elements() {
+			he, err := Hash(element)
+			if err != nil {
+				return 0, err
+			}
+			h += he // order independent
+		}
+		return h, nil
+	default:
+		return 0, fmt.Errorf("unhashable value")
+	}
+}
+
+// mixHash folds a 64-bit integer into a well-distributed 32-bit hash
+func mixHash(x uint64) uint32 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return uint32(x)
+}
+
+func stringHash(kind, s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(kind))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
+
+func orderedHash(seed uint32, elements []MalType) (uint32, error) {
+	h := seed
+	for _, element := range elements {
+		he, err := Hash(element)
+		if err != nil {
+			return 0, err
+		}
+		h = h*31 + he
+	}
+	return h, nil
+}
+
+// MaxHashedSeqLength is the maximum number of elements of a lazy sequence to hash
+// As a lazy sequence may be infinite, hashing a longer one is an error rather than never returning.
+const MaxHashedSeqLength = 1 << 20
+
+// seqHash hashes a lazy sequence like orderedHash() does for a list, realizing one element at a time
+func seqHash(seq MalType) (uint32, error) {
+	var h uint32 = 0x1157
+	for i := 0; ; i++ {
+		first, rest, ok, err := SeqNext(seq)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return h, nil
+		}
+		if i == MaxHashedSeqLength {
+			return 0, fmt.Errorf("can't hash a lazy sequence of more than %d elements", MaxHashedSeqLength)
+		}
+		he, err := Hash(first)
+		if err != nil {
+			return 0, err
+		}
+		h = h*31 + he
+		seq = rest
+	}
+}
+
+// isSequential tells whether `v` is a list or a lazy sequence, which can be equal to each other
+func isSequential(v MalType) bool {
+	switch v.(type) {
+	case MalList, *MalLazySeq:
+		return true
+	}
+	return false
+}
+
+// equalSeqs compares two sequential values one element at a time, so that it returns as soon as
+// they differ, even if one of them is infinite
+func equalSeqs(a, b MalType) (bool, error) {
+	for {
+		firstA, restA, okA, err := SeqNext(a)
+		if err != nil {
+			return false, err
+		}
+		firstB, restB, okB, err := SeqNext(b)
+		if err != nil {
+			return false, err
+		}
+		if !okA || !okB {
+			return okA == okB, nil
+		}
+		if same, err := Equal(firstA, firstB); err != nil || !same {
+			return false, err
+		}
+		a, b = restA, restB
+	}
+}
+
+// Equal compares two values by their structure
+// A lazy sequence equals to a list of the same elements, but a list never equals to a vector.
+// Like Clojure, an integer never equals to a float.
+func Equal(a, b MalType) (bool, error) {
+	// a lazy sequence equals to a list with the same elements
+	_, lazyA := a.(*MalLazySeq)
+	_, lazyB := b.(*MalLazySeq)
+	if lazyA || lazyB {
+		if !isSequential(a) || !isSequential(b) {
+			return false, nil
+		}
+		return equalSeqs(a, b)
+	}
+	switch first := a.(type) {
+	case MalNumber, MalFloat, MalString, MalChar, MalKeyword, MalSymbol, MalLiteral, MalRegex, MalUUID:
+		return a == b, nil // they are of the same type and value
+	case *MalMultiFn, *MalTracedFn: // the same multimethod or traced function
+		return a == b, nil
+	case MalInst: // the same instant even in different time zones
+		second, ok := b.(MalInst)
+		return ok && first.Value.Equal(second.Value), nil
+	case MalBytes:
+		second, ok := b.(MalBytes)
+		return ok && bytes.Equal(first.Value, second.Value), nil
+	case MalBigInt:
+		second, ok := b.(MalBigInt)
+		return ok && first.Value.Cmp(second.Value) == 0, nil
+	case MalRatio:
+		second, ok := b.(MalRatio)
+		return ok && first.Value.Cmp(second.Value) == 0, nil
+	case MalList:
+		second, ok := b.(MalList)
+		// necessary condition for equality: second should be a list of the same length as first
+		if !ok || len(first) != len(second) {
+			return false, nil
+		}
+		return equalElements(first, second)
+	case MalVector:
+		second, ok := b.(MalVector)
+		if !ok || first.Count() != second.Count() {
+			return false, nil
+		}
+		return equalElements(first.Slice(), second.Slice())
+	case MalHashmap:
+		second, ok := b.(MalHashmap)
+		if !ok || first.Count() != second.Count() {
+			return false, nil
+		}
+		for _, entry := range first.Entries() {
+			value, found := second.Get(entry.Key)
+			if !found {
+				return false, nil
+			}
+			if same, err := Equal(entry.Value, value); err != nil || !same {
+				return false, err
+			}
+		}
+		return true, nil
+	case MalRecord: // records of different types are never equal, even with the same fields
+		second, ok := b.(MalRecord)
+		if !ok || first.Type != second.Type {
+			return false, nil
+		}
+		return Equal(first.fields, second.fields)
+	case MalSet:
+		second, ok := b.(MalSet)
+		if !ok || first.Count() != second.Count() {
+			return false, nil
+		}
+		for _, element := range first.Elements() {
+			if !second.Contains(element) {
+				return false, nil
+			}
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("sorry but unimplemented yet")
+	}
+}
+
+func equalElements(first, second []MalType) (bool, error) {
+	for i := range first {
+		if same, err := Equal(first[i], second[i]); err != nil || !same {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,246 @@
+package types
+
+import "math/bits"
+
+// MalHashmap is a persistent hash map implemented as a hash array mapped trie (HAMT)
+// Any hashable value (see Hash()) can be a key, and keys are compared with Equal().
+// Each level of the trie consumes 5 bits of the hash, and keys with the same hash end up in
+// a collision node. The zero value is an empty map.
+type MalHashmap struct {
+	count int
+	root  *hamtNode
+}
+
+// MapEntry is a key-value pair in MalHashmap
+type MapEntry struct {
+	Key   MalType
+	Value MalType
+}
+
+const (
+	hamtBits     = 5
+	hamtMask     = 1<<hamtBits - 1
+	hamtMaxShift = 30 // the last level, which consumes the remaining 2 bits
+)
+
+// hamtEntry is either a key-value pair or a sub-node
+type hamtEntry struct {
+	hash  uint32
+	key   MalType
+	value MalType
+	node  *hamtNode
+}
+
+// hamtNode holds the entries of the used positions indicated by bitmap, in the order of positions
+// A collision node holds entries of the same hash instead, and its bitmap is unused
+type hamtNode struct {
+	bitmap    uint32
+	entries   []hamtEntry
+	collision bool
+}
+
+// NewHashmap creates a hash map with `kvs`, which are keys and values alternately
+func NewHashmap(kvs ...MalType) (MalHashmap, error) {
+	hm := MalHashmap{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		var err error
+		if hm, err = hm.Assoc(kvs[i], kvs[i+1]); err != nil {
+			return MalHashmap{}, err
+		}
+	}
+	return hm, nil
+}
+
+// Count returns the number of entries
+func (hm MalHashmap) Count() int {
+	return hm.count
+}
+
+// Get looks up `key` and tells whether it's found
+func (hm MalHashmap) Get(key MalType) (MalType, bool) {
+	hash, err := Hash(key)
+	if err != nil || hm.root == nil { // an unhashable key can't be in the map
+		return nil, false
+	}
+	return hm.root.find(0, hash, key)
+}
+
+// Assoc returns a new map with `key` mapped to `value`
+// An error is returned if `key` is unhashable
+func (hm MalHashmap) Assoc(key, value MalType) (MalHashmap, error) {
+	hash, err := Hash(key)
+	if err != nil {
+		return MalHashmap{}, err
+	}
+	root, added := hm.root.assoc(0, hamtEntry{hash: hash, key: key, value: value})
+	count := hm.count
+	if added {
+		count++
+	}
+	return MalHashmap{count, root}, nil
+}
+
+// Dissoc returns a new map without `key`
+func (hm MalHashmap) Dissoc(key MalType) MalHashmap {
+	hash, err := Hash(key)
+	if err != nil || hm.root == nil {
+		return hm
+	}
+	root, removed := hm.root.dissoc(0, hash, key)
+	if !removed {
+		return hm
+	}
+	return MalHashmap{hm.count - 1, root}
+}
+
+// Entries returns all key-value pairs, in the order of their hashes
+func (hm MalHashmap) Entries() []MapEntry {
+	result := make([]MapEntry, 0, hm.count)
+	var walk func(node *hamtNode)
+	walk = func(node *hamtNode) {
+		for _, e := range node.entries {
+			if e.node != nil {
+				walk(e.node)
+			} else {
+				result = append(result, MapEntry{e.key, e.value})
+			}
+		}
+	}
+	if hm.root != nil {
+		walk(hm.root)
+	}
+	return result
+}
+
+// sameKey tells whether `e` is a key-value pair with the key `key`
+func (e hamtEntry) sameKey(hash uint32, key MalType) bool {
+	if e.node != nil || e.hash != hash {
+		return false
+	}
+	same, _ := Equal(e.key, key)
+	return same
+}
+
+// position returns the bit of `hash` at the level of `shift`, and the index of its entry
+func (n *hamtNode) position(shift uint, hash uint32) (uint32, int) {
+	bit := uint32(1) << ((hash >> shift) & hamtMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode) find(shift uint, hash uint32, key MalType) (MalType, bool) {
+	if n.collision {
+		for _, e := range n.entries {
+			if e.sameKey(hash, key) {
+				return e.value, true
+			}
+		}
+		return nil, false
+	}
+	bit, index := n.position(shift, hash)
+	if n.bitmap&bit == 0 {
+		return nil, false
+	}
+	e := n.entries[index]
+	if e.node != nil {
+		return e.node.find(shift+hamtBits, hash, key)
+	}
+	if e.sameKey(hash, key) {
+		return e.value, true
+	}
+	return nil, false
+}
+
+// withEntry returns a copy of `n` with the entry at `index` replaced by `e`
+func (n *hamtNode) withEntry(index int, e hamtEntry) *hamtNode {
+	entries := make([]hamtEntry, len(n.entries))
+	copy(entries, n.entries)
+	entries[index] = e
+	return &hamtNode{n.bitmap, entries, n.collision}
+}
+
+// assoc returns a copy of `n` (which may be nil) with `entry` added or replacing the same key
+// It also tells whether a new key is added
+func (n *hamtNode) assoc(shift uint, entry hamtEntry) (*hamtNode, bool) {
+	if n == nil {
+		n = &hamtNode{}
+	}
+	if n.collision {
+		if entry.hash != n.entries[0].hash { // put the collision node into a normal one
+			bit, _ := (&hamtNode{}).position(shift, n.entries[0].hash)
+			parent := &hamtNode{bitmap: bit, entries: []hamtEntry{{node: n}}}
+			return parent.assoc(shift, entry)
+		}
+		for i, e := range n.entries {
+			if e.sameKey(entry.hash, entry.key) {
+				return n.withEntry(i, entry), false
+			}
+		}
+		entries := append(append([]hamtEntry{}, n.entries...), entry)
+		return &hamtNode{entries: entries, collision: true}, true
+	}
+	bit, index := n.position(shift, entry.hash)
+	if n.bitmap&bit == 0 { // a free position
+		entries := make([]hamtEntry, 0, len(n.entries)+1)
+		entries = append(append(append(entries, n.entries[:index]...), entry), n.entries[index:]...)
+		return &hamtNode{bitmap: n.bitmap | bit, entries: entries}, true
+	}
+	e := n.entries[index]
+	if e.node != nil {
+		child, added := e.node.assoc(shift+hamtBits, entry)
+		return n.withEntry(index, hamtEntry{node: child}), added
+	}
+	if e.sameKey(entry.hash, entry.key) {
+		return n.withEntry(index, entry), false
+	}
+	// two different keys at the same position, so move them into a sub-node
+	var child *hamtNode
+	if e.hash == entry.hash || shift >= hamtMaxShift {
+		child = &hamtNode{entries: []hamtEntry{e, entry}, collision: true}
+	} else {
+		child, _ = (*hamtNode)(nil).assoc(shift+hamtBits, e)
+		child, _ = child.assoc(shift+hamtBits, entry)
+	}
+	return n.withEntry(index, hamtEntry{node: child}), true
+}
+
+// dissoc returns a copy of `n` without `key`, which is nil if nothing is left
+// It also tells whether the key is found and removed
+func (n *hamtNode) dissoc(shift uint, hash uint32, key MalType) (*hamtNode, bool) {
+	index := -1
+	var bit uint32
+	if n.collision {
+		for i, e := range n.entries {
+			if e.sameKey(hash, key) {
+				index = i
+			}
+		}
+	} else {
+		bit, index = n.position(shift, hash)
+		if n.bitmap&bit == 0 {
+			return n, false
+		}
+		if e := n.entries[index]; e.node != nil {
+			child, removed := e.node.dissoc(shift+hamtBits, hash, key)
+			if !removed {
+				return n, false
+			}
+			if child != nil {
+				if len(child.entries) == 1 && child.entries[0].node == nil { // pull a single pair up
+					return n.withEntry(index, child.entries[0]), true
+				}
+				return n.withEntry(index, hamtEntry{node: child}), true
+			}
+		} else if !e.sameKey(hash, key) {
+			return n, false
+		}
+	}
+	if index < 0 {
+		return n, false
+	}
+	if len(n.entries) == 1 {
+		return nil, true
+	}
+	entries := make([]hamtEntry, 0, len(n.entries)-1)
+	entries = append(append(entries, n.entries[:index]...), n.entries[index+1:]...)
+	return &hamtNode{n.bitmap &^ bit, entries, n.collision}, true
+}
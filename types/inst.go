@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// MalInst is an instant in time, written as #inst "2026-10-17T00:00:00Z"
+type MalInst struct {
+	Value time.Time
+}
+
+// MalUUID is a universally unique identifier in its canonical lower-case form,
+// written as #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"
+type MalUUID struct {
+	Value string
+}
@@ -0,0 +1,109 @@
+package types
+
+import "fmt"
+
+// MalLazySeq is a sequence whose elements are computed only when needed
+// It's either empty or a pair of its first element and the rest sequence, which is known after
+// the thunk gets called. The thunk is called at most once and its result is cached.
+type MalLazySeq struct {
+	thunk    func() (MalType, error)
+	realized bool
+	empty    bool
+	first    MalType
+	rest     MalType // nil, MalList or *MalLazySeq
+}
+
+// NewLazySeq creates a lazy sequence whose content is the sequence returned by `thunk`
+func NewLazySeq(thunk func() (MalType, error)) *MalLazySeq {
+	return &MalLazySeq{thunk: thunk}
+}
+
+// Cons creates a realized lazy sequence with `first` followed by `rest`
+// Note that `rest` won't be realized here, so it's fine for it to be infinite
+func Cons(first, rest MalType) *MalLazySeq {
+	return &MalLazySeq{realized: true, first: first, rest: rest}
+}
+
+// Realize calls the thunk (if not yet) to find out whether the sequence is empty,
+// and if not, what its first element and the rest are
+func (ls *MalLazySeq) Realize() error {
+	if ls.realized {
+		return nil
+	}
+	value, err := ls.thunk()
+	if err != nil {
+		return err
+	}
+	first, rest, ok, err := SeqNext(value)
+	if err != nil {
+		return err
+	}
+	ls.realized, ls.thunk = true, nil // let the closure get garbage collected
+	ls.empty, ls.first, ls.rest = !ok, first, rest
+	return nil
+}
+
+// SeqNext splits a sequential value into its first element and the rest of it
+// `ok` is false if the sequence is empty. Lazy sequences are realized if necessary.
+func SeqNext(seq MalType) (first, rest MalType, ok bool, err error) {
+	switch t := seq.(type) {
+	case MalLiteral:
+		if t == MalNil {
+			return nil, MalNil, false, nil
+		}
+	case MalList:
+		if len(t) == 0 {
+			return nil, MalList{}, false, nil
+		}
+		return t[0], t[1:], true, nil
+	case MalVector: // the rest of a vector is a lazy sequence over it, so the vector is never copied
+		if t.Count() == 0 {
+			return nil, MalList{}, false, nil
+		}
+		first, _ := t.Nth(0)
+		return first, vectorSeq(t, 1), true, nil
+	case *MalLazySeq:
+		if err := t.Realize(); err != nil {
+			return nil, nil, false, err
+		}
+		if t.empty {
+			return nil, MalList{}, false, nil
+		}
+		return t.first, t.rest, true, nil
+	}
+	return nil, nil, false, fmt.Errorf("can't iterate over a non-sequence")
+}
+
+// vectorSeq returns the lazy sequence of the elements of `vec` from index `i`
+func vectorSeq(vec MalVector, i int) MalType {
+	if i >= vec.Count() {
+		return MalList{}
+	}
+	return NewLazySeq(func() (MalType, error) {
+		element, _ := vec.Nth(i)
+		return Cons(element, vectorSeq(vec, i+1)), nil
+	})
+}
+
+// SeqToList realizes all elements of a sequential value into a MalList
+// Never call it on an infinite sequence as it will never return
+func SeqToList(seq MalType) (MalList, error) {
+	if lst, ok := seq.(MalList); ok {
+		return lst, nil
+	}
+	if vec, ok := seq.(MalVector); ok {
+		return vec.Slice(), nil
+	}
+	result := MalList{}
+	for {
+		first, rest, ok, err := SeqNext(seq)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return result, nil
+		}
+		result = append(result, first)
+		seq = rest
+	}
+}
@@ -0,0 +1,9 @@
+package types
+
+// MalMultiFn is a multimethod, which calls the method registered for the value returned by its
+// dispatch function. It's always used as a pointer so that methods can be added after creation.
+type MalMultiFn struct {
+	Name     string
+	Dispatch MalType    // a function
+	Methods  MalHashmap // dispatch value => method
+}
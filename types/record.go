@@ -0,0 +1,84 @@
+package types
+
+// MalRecordType is a named type of records defined by defrecord
+type MalRecordType struct {
+	Name   string
+	Fields []MalKeyword // declared fields, in order
+}
+
+// MalRecord is a value of a record type, which behaves like a hash map keyed by its fields
+// Like Clojure, keys other than declared fields can be added with assoc.
+type MalRecord struct {
+	Type   *MalRecordType
+	fields MalHashmap
+}
+
+// recordTypes are all defined record types by name, so that the reader can read records back
+var recordTypes = make(map[string]*MalRecordType)
+
+// DefineRecordType creates a record type and registers it, replacing any type of the same name
+func DefineRecordType(name string, fields []MalKeyword) *MalRecordType {
+	rt := &MalRecordType{name, fields}
+	recordTypes[name] = rt
+	return rt
+}
+
+// LookupRecordType returns the record type registered with `name`
+func LookupRecordType(name string) (*MalRecordType, bool) {
+	rt, ok := recordTypes[name]
+	return rt, ok
+}
+
+// NewRecord creates a record of `rt` with `fields`, where missing declared fields are nil
+func NewRecord(rt *MalRecordType, fields MalHashmap) MalRecord {
+	for _, field := range rt.Fields {
+		if _, ok := fields.Get(field); !ok {
+			fields, _ = fields.Assoc(field, MalNil) // a keyword is always hashable
+		}
+	}
+	return MalRecord{rt, fields}
+}
+
+// IsField tells whether `key` is a declared field of the record type
+func (rt *MalRecordType) IsField(key MalType) bool {
+	for _, field := range rt.Fields {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Get looks up the value of `key`
+func (r MalRecord) Get(key MalType) (MalType, bool) {
+	return r.fields.Get(key)
+}
+
+// Assoc returns a new record of the same type with `key` mapped to `value`
+func (r MalRecord) Assoc(key, value MalType) (MalRecord, error) {
+	fields, err := r.fields.Assoc(key, value)
+	if err != nil {
+		return MalRecord{}, err
+	}
+	return MalRecord{r.Type, fields}, nil
+}
+
+// Fields returns all fields as a hash map
+func (r MalRecord) Fields() MalHashmap {
+	return r.fields
+}
+
+// Entries returns the declared fields in order, followed by other keys
+func (r MalRecord) Entries() []MapEntry {
+	result := make([]MapEntry, 0, r.fields.Count())
+	for _, field := range r.Type.Fields {
+		value, _ := r.fields.Get(field)
+		result = append(result, MapEntry{field, value})
+	}
+	for _, entry := range r.fields.Entries() {
+		if !r.Type.IsField(entry.Key) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
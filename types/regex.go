@@ -0,0 +1,28 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// MalRegex is a compiled regular expression in Go syntax, written as #"\d+"
+// Like Clojure, two regexes are equal only if they are the same one.
+// Use NewRegex() to create one, which also compiles the anchored version for matching whole strings.
+type MalRegex struct {
+	Value    *regexp.Regexp
+	Anchored *regexp.Regexp // ^(?:pattern)$
+}
+
+// NewRegex compiles `pattern` into a MalRegex
+func NewRegex(pattern string) (MalRegex, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return MalRegex{}, fmt.Errorf("invalid regex: %v", err)
+	}
+	// anchor it so that the longest alternative is tried, e.g., a|ab matches "ab" as a whole
+	anchored, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return MalRegex{}, fmt.Errorf("invalid regex: %v", err)
+	}
+	return MalRegex{re, anchored}, nil
+}
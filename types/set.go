@@ -0,0 +1,55 @@
+package types
+
+// MalSet is a persistent set of mal values, backed by a MalHashmap whose keys are the elements
+// The zero value is an empty set.
+type MalSet struct {
+	elements MalHashmap
+}
+
+// NewSet creates a set of `values`
+func NewSet(values ...MalType) (MalSet, error) {
+	return MalSet{}.Add(values...)
+}
+
+// Add returns a new set with all elements of `s` and `values`
+// An error is returned if any of `values` is unhashable
+func (s MalSet) Add(values ...MalType) (MalSet, error) {
+	elements := s.elements
+	for _, v := range values {
+		var err error
+		if elements, err = elements.Assoc(v, v); err != nil {
+			return MalSet{}, err
+		}
+	}
+	return MalSet{elements}, nil
+}
+
+// Remove returns a new set without `values`
+func (s MalSet) Remove(values ...MalType) MalSet {
+	elements := s.elements
+	for _, v := range values {
+		elements = elements.Dissoc(v)
+	}
+	return MalSet{elements}
+}
+
+// Contains tells whether `v` is an element of `s`
+func (s MalSet) Contains(v MalType) bool {
+	_, ok := s.elements.Get(v)
+	return ok
+}
+
+// Count returns the number of elements
+func (s MalSet) Count() int {
+	return s.elements.Count()
+}
+
+// Elements returns all elements in a new slice
+func (s MalSet) Elements() []MalType {
+	entries := s.elements.Entries()
+	result := make([]MalType, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.Value)
+	}
+	return result
+}
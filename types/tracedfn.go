@@ -0,0 +1,10 @@
+package types
+
+// MalTracedFn is a function wrapped by trace, which prints its calls and keeps the original function
+// to be restored by untrace. It's always used as a pointer so that untrace can tell whether a name is
+// still bound to the same wrapper.
+type MalTracedFn struct {
+	Name     string
+	Original MalType     // the function traced
+	Function MalFunction // the wrapper printing the calls
+}
@@ -1,11 +1,29 @@
 package types
 
+import "math/big"
+
 type MalType interface{}
 
 type MalNumber struct {
 	Value int
 }
 
+// MalBigInt is an integer out of the range of MalNumber
+// Use NormalizeBigInt() to create one so that small values are always MalNumber
+type MalBigInt struct {
+	Value *big.Int
+}
+
+// MalRatio is an exact fraction whose denominator isn't 1
+// Use NormalizeRat() to create one so that integral values are always integers
+type MalRatio struct {
+	Value *big.Rat
+}
+
+type MalFloat struct {
+	Value float64
+}
+
 type MalString struct {
 	Value string
 }
@@ -24,10 +42,6 @@ const (
 
 type MalList []MalType
 
-type MalVector []MalType
-
-type MalHashmap map[MalType]MalType
-
 type MalSymbol struct {
 	Value string
 }
@@ -46,3 +60,7 @@ type MalEnv interface {
 	Find(key MalSymbol) MalEnv
 	Get(key MalSymbol) (MalType, error)
 }
+
+// EvalFunc evaluates `ast` within `env`, i.e., the signature of EVAL
+// It's passed to the packages that evaluate forms but can't import the main package
+type EvalFunc func(ast MalType, env MalEnv) (MalType, error)
@@ -1,5 +1,7 @@
 package types
 
+import "math/big"
+
 // ToMalBool converts a Golang bool to mal bool
 func ToMalBool(b bool) MalLiteral {
 	if b {
@@ -19,3 +21,21 @@ func NotMalBool(mb MalLiteral) MalLiteral {
 		return mb
 	}
 }
+
+// NormalizeBigInt converts `b` to MalNumber if it fits, or MalBigInt otherwise
+// Note that `b` shouldn't be modified later as it may be held by the result
+func NormalizeBigInt(b *big.Int) MalType {
+	if b.IsInt64() && int64(int(b.Int64())) == b.Int64() {
+		return MalNumber{Value: int(b.Int64())}
+	}
+	return MalBigInt{Value: b}
+}
+
+// NormalizeRat converts `r` to an integer if its denominator is 1, or MalRatio otherwise
+// Note that `r` shouldn't be modified later as it may be held by the result
+func NormalizeRat(r *big.Rat) MalType {
+	if r.IsInt() {
+		return NormalizeBigInt(new(big.Int).Set(r.Num()))
+	}
+	return MalRatio{Value: r}
+}
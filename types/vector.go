@@ -0,0 +1,155 @@
+package types
+
+import "fmt"
+
+// MalVector is a persistent vector, i.e., an immutable vector whose "modified" versions share most
+// of their structure with it. Like Clojure, it's a 32-way trie holding all elements but the last
+// ones, which are kept in a tail for fast appending. Thus Nth(), Conj() and Assoc() are O(log32 n).
+// The zero value is an empty vector.
+type MalVector struct {
+	count int
+	shift uint // the number of index bits consumed by the levels above leaves
+	root  *vectorNode
+	tail  []MalType // never modified in place as it may be shared
+}
+
+const (
+	vectorBits  = 5
+	vectorWidth = 1 << vectorBits
+	vectorMask  = vectorWidth - 1
+)
+
+// vectorNode is either an internal node with children or a leaf with values
+type vectorNode struct {
+	children []*vectorNode
+	values   []MalType
+}
+
+// NewVector creates a vector of `elements`
+func NewVector(elements ...MalType) MalVector {
+	vec := MalVector{}
+	for _, element := range elements {
+		vec = vec.Conj(element)
+	}
+	return vec
+}
+
+// Count returns the number of elements
+func (v MalVector) Count() int {
+	return v.count
+}
+
+// tailOffset returns the index of the first element in the tail
+func (v MalVector) tailOffset() int {
+	if v.count < vectorWidth {
+		return 0
+	}
+	return ((v.count - 1) >> vectorBits) << vectorBits
+}
+
+// leafFor returns the values of the leaf (or the tail) holding the element at `i`
+func (v MalVector) leafFor(i int) []MalType {
+	if i >= v.tailOffset() {
+		return v.tail
+	}
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.children[(i>>level)&vectorMask]
+	}
+	return node.values
+}
+
+// Nth returns the element at index `i`
+func (v MalVector) Nth(i int) (MalType, error) {
+	if i < 0 || i >= v.count {
+		return nil, fmt.Errorf("index %d out of bounds for a vector of %d element(s)", i, v.count)
+	}
+	return v.leafFor(i)[i&vectorMask], nil
+}
+
+// Slice returns all elements in a new slice
+func (v MalVector) Slice() []MalType {
+	result := make([]MalType, 0, v.count)
+	for i := 0; i < v.count; i += vectorWidth {
+		result = append(result, v.leafFor(i)...)
+	}
+	return result
+}
+
+// Conj returns a new vector with `value` appended
+func (v MalVector) Conj(value MalType) MalVector {
+	if v.count-v.tailOffset() < vectorWidth { // there is room in the tail
+		tail := make([]MalType, len(v.tail), len(v.tail)+1)
+		copy(tail, v.tail)
+		return MalVector{v.count + 1, v.shift, v.root, append(tail, value)}
+	}
+	// the tail is full, so push it into the trie
+	tailNode := &vectorNode{values: v.tail}
+	root, shift := v.root, v.shift
+	if root == nil { // the zero value
+		root, shift = &vectorNode{}, vectorBits
+	}
+	if (v.count >> vectorBits) > (1 << shift) { // the trie is full, so add a new level on top
+		root = &vectorNode{children: []*vectorNode{root, newPath(shift, tailNode)}}
+		shift += vectorBits
+	} else {
+		root = v.pushTail(shift, root, tailNode)
+	}
+	return MalVector{v.count + 1, shift, root, []MalType{value}}
+}
+
+// newPath creates the nodes from `level` down to `leaf`
+func newPath(level uint, leaf *vectorNode) *vectorNode {
+	if level == 0 {
+		return leaf
+	}
+	return &vectorNode{children: []*vectorNode{newPath(level-vectorBits, leaf)}}
+}
+
+// pushTail returns a copy of `parent` at `level` with `tailNode` added as the last leaf
+func (v MalVector) pushTail(level uint, parent, tailNode *vectorNode) *vectorNode {
+	index := ((v.count - 1) >> level) & vectorMask
+	children := make([]*vectorNode, index+1)
+	copy(children, parent.children)
+	if level == vectorBits {
+		children[index] = tailNode
+	} else if child := children[index]; child != nil {
+		children[index] = v.pushTail(level-vectorBits, child, tailNode)
+	} else {
+		children[index] = newPath(level-vectorBits, tailNode)
+	}
+	return &vectorNode{children: children}
+}
+
+// Assoc returns a new vector with the element at `i` replaced by `value`
+// If `i` is the count of elements, `value` is appended instead
+func (v MalVector) Assoc(i int, value MalType) (MalVector, error) {
+	if i == v.count {
+		return v.Conj(value), nil
+	}
+	if i < 0 || i > v.count {
+		return MalVector{}, fmt.Errorf("index %d out of bounds for a vector of %d element(s)", i, v.count)
+	}
+	if i >= v.tailOffset() {
+		tail := make([]MalType, len(v.tail))
+		copy(tail, v.tail)
+		tail[i&vectorMask] = value
+		return MalVector{v.count, v.shift, v.root, tail}, nil
+	}
+	return MalVector{v.count, v.shift, assocNode(v.root, v.shift, i, value), v.tail}, nil
+}
+
+// assocNode returns a copy of `node` at `level` with the element at `i` replaced by `value`
+func assocNode(node *vectorNode, level uint, i int, value MalType) *vectorNode {
+	if level == 0 {
+		values := make([]MalType, len(node.values))
+		copy(values, node.values)
+		values[i&vectorMask] = value
+		return &vectorNode{values: values}
+	}
+	children := make([]*vectorNode, len(node.children))
+	copy(children, node.children)
+	index := (i >> level) & vectorMask
+	children[index] = assocNode(children[index], level-vectorBits, i, value)
+	return &vectorNode{children: children}
+}